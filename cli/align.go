@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/aligner"
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/io"
+	"DNA-Sequence-Alignments/dna_aligner/output"
+	"DNA-Sequence-Alignments/dna_aligner/regions"
+	"DNA-Sequence-Alignments/dna_aligner/report"
+	"flag"
+	"fmt"
+	goio "io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Align implements `dnaseq align`: align one query against one reference.
+func Align(args []string) int {
+	fs := flag.NewFlagSet("align", flag.ContinueOnError)
+	queryFile := fs.String("q", "", "query sequence file (required)")
+	refFile := fs.String("r", "", "reference sequence file (required)")
+	outputFile := fs.String("o", "", "output file (default stdout)")
+	format := fs.String("f", "tuples", "output format: tuples, paf or sam")
+	minMatchLen := fs.Int("min-match", 0, "minimum anchor length (0 selects it adaptively)")
+	statsFile := fs.String("stats", "", "summary report JSON file (default: <output>.stats.json when -o is given)")
+	refBedFile := fs.String("ref-bed", "", "write uncovered and multiply covered reference intervals to this BED file")
+	threads := fs.Int("t", 1, "number of worker threads")
+	maxEValue := fs.Float64("max-evalue", 0, "drop segments with an E-value above this (0 keeps all)")
+	noFallback := fs.Bool("no-fallback", false, "leave query regions without matches unaligned instead of adding fallback segments")
+	tandemCopies := fs.Bool("tandem-copies", false, "report every copy of a tandem expansion in the query as its own segment (PAF/SAM tag ci:i)")
+	queryName := fs.String("query-name", "", "query name in PAF/SAM records (default: query file name)")
+	refName := fs.String("ref-name", "", "reference name in PAF/SAM records (default: reference file name)")
+	params := addParamFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *queryFile == "" || *refFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: both -q and -r are required")
+		fs.Usage()
+		return 2
+	}
+	if !validFormat(*format) {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q\n", *format)
+		return 2
+	}
+	if *queryName == "" {
+		*queryName = fileStem(*queryFile)
+	}
+	if *refName == "" {
+		*refName = fileStem(*refFile)
+	}
+
+	common.LogWriter = os.Stderr
+
+	p, err := params.resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	querySeq, refSeq, err := readSequencePair(*queryFile, *refFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	res := aligner.Align(querySeq, refSeq, aligner.Options{
+		MinMatchLength: *minMatchLen,
+		NoFallback:     *noFallback,
+		MaxEValue:      *maxEValue,
+		Threads:        *threads,
+		TandemCopies:   *tandemCopies,
+		Params:         &p,
+	})
+
+	if *statsFile == "" && *outputFile != "" {
+		*statsFile = *outputFile + ".stats.json"
+	}
+	if *statsFile != "" {
+		if err := writeSummary(*statsFile, &p, len(querySeq), len(refSeq), res.Segments); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	if *refBedFile != "" {
+		if err := writeReferenceBED(*refBedFile, *refName, len(refSeq), res.Segments); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	out, closeOut, err := createOutput(*outputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer closeOut()
+
+	pair := output.Pair{QueryName: *queryName, Query: querySeq, RefName: *refName, Ref: refSeq}
+	if *format == "sam" {
+		err = output.WriteSAMHeader(out, *refName, len(refSeq), params.description(p))
+	}
+	if err == nil {
+		err = writeSegments(out, *format, pair, res, false)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// paramFlags are the -preset and -config flags that select the aligner parameters.
+type paramFlags struct {
+	preset *string
+	file   *string
+}
+
+// addParamFlags registers -preset and -config on fs.
+func addParamFlags(fs *flag.FlagSet) paramFlags {
+	return paramFlags{
+		preset: fs.String("preset", config.DefaultPreset, "parameter preset: "+strings.Join(config.PresetNames(), ", ")),
+		file:   fs.String("config", "", "JSON file (.json) overriding individual parameters of the preset"),
+	}
+}
+
+// resolve returns the parameters selected by the flags.
+func (f paramFlags) resolve() (config.Params, error) {
+	return config.Resolve(*f.preset, *f.file)
+}
+
+// description describes the parameters p selected by the flags for output headers, as the
+// preset name and the full configuration in the JSON form accepted by -config.
+func (f paramFlags) description(p config.Params) string {
+	desc := "dna_aligner preset=" + *f.preset
+	if *f.file != "" {
+		desc += " config_file=" + *f.file
+	}
+	return desc + " config=" + p.String()
+}
+
+// validFormat reports whether format is one of the alignment output formats.
+func validFormat(format string) bool {
+	return format == "tuples" || format == "paf" || format == "sam"
+}
+
+// writeSegments writes the alignment result of one query in the given format (without any file
+// header). With named set, tuples lines are prefixed by the query name so several queries can share
+// a file.
+func writeSegments(w goio.Writer, format string, pair output.Pair, res aligner.Result, named bool) error {
+	switch format {
+	case "tuples":
+		if named {
+			_, err := fmt.Fprintf(w, "%s\t%s\n", pair.QueryName, output.FormatTuples(res.Segments))
+			return err
+		}
+		_, err := fmt.Fprintln(w, output.FormatTuples(res.Segments))
+		return err
+	case "paf":
+		return output.WritePAF(w, pair, res.Segments, res.Alignments)
+	case "sam":
+		return output.WriteSAMRecords(w, pair, res.Segments, res.Alignments)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// readSequencePair reads the query and reference sequence files.
+func readSequencePair(queryFile, refFile string) (string, string, error) {
+	query, err := io.ReadRecord(queryFile)
+	if err != nil {
+		return "", "", fmt.Errorf("reading query file '%s': %w", queryFile, err)
+	}
+	ref, err := io.ReadRecord(refFile)
+	if err != nil {
+		return "", "", fmt.Errorf("reading reference file '%s': %w", refFile, err)
+	}
+	return query.Seq, ref.Seq, nil
+}
+
+// createOutput opens path for writing, or returns stdout when path is empty.
+// The returned function closes the file.
+func createOutput(path string) (goio.Writer, func(), error) {
+	if path == "" {
+		return os.Stdout, func() {}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating output file '%s': %w", path, err)
+	}
+	return f, func() { f.Close() }, nil
+}
+
+// writeFile creates path and fills it with write. Errors from closing the file are reported too,
+// since a failed close can leave the file truncated.
+func writeFile(path string, write func(goio.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating file '%s': %w", path, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing file '%s': %w", path, err)
+	}
+	return f.Close()
+}
+
+// writeSummary writes the JSON summary report of an alignment result, obtained with parameters p,
+// to path.
+func writeSummary(path string, p *config.Params, queryLen, refLen int, segments []common.Segment) error {
+	summary := report.Summarize(queryLen, refLen, segments)
+	summary.Config = p
+	return writeFile(path, func(w goio.Writer) error { return report.WriteJSON(w, summary) })
+}
+
+// writeReferenceBED writes the reference intervals not covered by any segment ("uncovered", score 0)
+// and those covered more than once ("multi", score = highest depth) to a BED file. Fallback
+// segments do not count (see regions.ComputeReferenceCoverage).
+func writeReferenceBED(path, refName string, refLen int, segments []common.Segment) error {
+	depth := regions.ComputeReferenceCoverage(refLen, segments)
+	var intervals []output.BEDInterval
+	for _, iv := range regions.FindUncoveredReferenceRegions(depth) {
+		intervals = append(intervals, output.BEDInterval{Start: iv[0], End: iv[1] + 1, Name: "uncovered"})
+	}
+	for _, iv := range regions.FindMultiplyCoveredReferenceRegions(depth, 2) {
+		intervals = append(intervals, output.BEDInterval{Start: iv.Start, End: iv.End + 1, Name: "multi", Score: iv.MaxDepth})
+	}
+	sort.SliceStable(intervals, func(i, j int) bool { return intervals[i].Start < intervals[j].Start })
+
+	return writeFile(path, func(w goio.Writer) error { return output.WriteBED(w, refName, intervals) })
+}
+
+// fileStem returns the file name without directory and extension.
+func fileStem(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
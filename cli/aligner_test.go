@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeScenario writes the query and reference of a scenario to dir and returns their paths.
+func writeScenario(t *testing.T, dir string, res simulate.Result) (string, string) {
+	t.Helper()
+	queryFile := filepath.Join(dir, "query.fa")
+	refFile := filepath.Join(dir, "ref.txt")
+	if err := os.WriteFile(queryFile, []byte(">sim_query\n"+res.Query+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(refFile, []byte(res.Ref+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return queryFile, refFile
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestAlignCommand(t *testing.T) {
+	dir := t.TempDir()
+	queryFile, refFile := writeScenario(t, dir, simulate.Scenarios[2].Generate(1))
+
+	var sb strings.Builder
+	for _, format := range []string{"tuples", "paf", "sam"} {
+		out := filepath.Join(dir, "out."+format)
+		args := []string{"-q", queryFile, "-r", refFile, "-o", out, "-f", format}
+		if format == "paf" {
+			args = append(args, "-ref-bed", filepath.Join(dir, "ref.bed"))
+		}
+		if code := Align(args); code != 0 {
+			t.Fatalf("align -f %s exited with %d", format, code)
+		}
+		fmt.Fprintf(&sb, "== %s\n%s\n", format, readFile(t, out))
+	}
+	fmt.Fprintf(&sb, "== ref.bed\n%s== stats\n%s", readFile(t, filepath.Join(dir, "ref.bed")), readFile(t, filepath.Join(dir, "out.tuples.stats.json")))
+	golden.AssertString(t, sb.String())
+
+	if code := Align([]string{"-q", queryFile}); code != 2 {
+		t.Errorf("missing -r: exit code %d, want 2", code)
+	}
+	if code := Align([]string{"-q", queryFile, "-r", refFile, "-f", "bam"}); code != 2 {
+		t.Errorf("unknown format: exit code %d, want 2", code)
+	}
+}
+
+func TestAlignPresets(t *testing.T) {
+	dir := t.TempDir()
+	queryFile, refFile := writeScenario(t, dir, simulate.Scenarios[0].Generate(1))
+	cfg := filepath.Join(dir, "params.json")
+	if err := os.WriteFile(cfg, []byte(`{"min_match_length": 40}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out.sam")
+	if code := Align([]string{"-q", queryFile, "-r", refFile, "-f", "sam", "-o", out, "-preset", "asm5", "-config", cfg}); code != 0 {
+		t.Fatalf("align -preset asm5 exited with %d", code)
+	}
+	sam := readFile(t, out)
+	if !strings.Contains(sam, "@CO\tdna_aligner preset=asm5 config_file="+cfg+` config={"default_k":10,"min_match_length":40,`) {
+		t.Errorf("SAM header does not record the configuration:\n%s", sam[:strings.Index(sam, "\n@CO")+300])
+	}
+	if !strings.Contains(readFile(t, out+".stats.json"), `"min_identity_threshold": 0.9`) {
+		t.Errorf("stats report does not record the configuration")
+	}
+
+	vcf := filepath.Join(dir, "out.vcf")
+	if code := Variants([]string{"-q", queryFile, "-r", refFile, "-o", vcf}); code != 0 {
+		t.Fatalf("variants exited with %d", code)
+	}
+	if !strings.Contains(readFile(t, vcf), `##dna_alignerConfig=dna_aligner preset=default config={"default_k":10,"min_match_length":28,`) {
+		t.Errorf("VCF header does not record the default configuration")
+	}
+
+	if code := Align([]string{"-q", queryFile, "-r", refFile, "-preset", "nope"}); code != 2 {
+		t.Errorf("unknown preset: exit code %d, want 2", code)
+	}
+	toml := filepath.Join(dir, "params.toml")
+	if err := os.WriteFile(toml, []byte("min_match_length = 40\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := Align([]string{"-q", queryFile, "-r", refFile, "-config", toml}); code != 2 {
+		t.Errorf("TOML config file: exit code %d, want 2", code)
+	}
+}
+
+func TestBatchCommand(t *testing.T) {
+	dir := t.TempDir()
+	var queries strings.Builder
+	ref := simulate.Scenarios[0].Generate(1).Ref
+	for i, sc := range simulate.Scenarios[:4] {
+		res := sc.Generate(1) // Same seed and length: every scenario mutates the same reference
+		if res.Ref != ref {
+			t.Fatalf("scenario %s uses a different reference", sc.Name)
+		}
+		fmt.Fprintf(&queries, ">q%d_%s\n%s\n", i, sc.Name, res.Query)
+	}
+	queryFile := filepath.Join(dir, "queries.fa")
+	refFile := filepath.Join(dir, "ref.txt")
+	if err := os.WriteFile(queryFile, []byte(queries.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(refFile, []byte(ref), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var want string
+	for _, jobs := range []string{"1", "3"} {
+		out := filepath.Join(dir, "out"+jobs+".paf")
+		if code := Batch([]string{"-q", queryFile, "-r", refFile, "-o", out, "-j", jobs}); code != 0 {
+			t.Fatalf("batch -j %s exited with %d", jobs, code)
+		}
+		got := readFile(t, out)
+		if want == "" {
+			want = got
+			golden.AssertString(t, got)
+		} else if got != want {
+			t.Errorf("batch -j %s output differs from -j 1", jobs)
+		}
+	}
+}
+
+func TestSimulateCommand(t *testing.T) {
+	dir := t.TempDir()
+	prefix := filepath.Join(dir, "sim")
+	args := []string{"-o", prefix, "-seed", "5", "-length", "3000", "-snp-rate", "0.01", "-indel-rate", "0.002", "-indel-mean", "2",
+		"-inversions", "1", "-inversion-len", "400", "-tandem-dups", "1", "-dup-len", "200", "-translocations", "1", "-translocation-len", "300"}
+	if code := Simulate(args); code != 0 {
+		t.Fatalf("simulate exited with %d", code)
+	}
+	var sb strings.Builder
+	for _, suffix := range []string{".truth.paf", ".events.tsv", ".ref.fa", ".query.fa"} {
+		fmt.Fprintf(&sb, "== %s\n%s", suffix, readFile(t, prefix+suffix))
+	}
+	golden.AssertString(t, sb.String())
+
+	// The reference given with -r is mutated as is and not written back.
+	if code := Simulate([]string{"-r", prefix + ".ref.fa", "-o", prefix + "2", "-seed", "5", "-f", "tuples"}); code != 0 {
+		t.Fatalf("simulate -r exited with %d", code)
+	}
+	if _, err := os.Stat(prefix + "2.ref.fa"); !os.IsNotExist(err) {
+		t.Errorf("reference written for -r input (err=%v)", err)
+	}
+	if truth := readFile(t, prefix+"2.truth.txt"); !strings.HasPrefix(truth, "[(0, ") {
+		t.Errorf("unexpected tuples truth %q", truth)
+	}
+}
+
+func TestEvalCommand(t *testing.T) {
+	dir := t.TempDir()
+	prefix := filepath.Join(dir, "sim")
+	if code := Simulate([]string{"-o", prefix, "-length", "2000", "-inversions", "1", "-inversion-len", "300"}); code != 0 {
+		t.Fatalf("simulate exited with %d", code)
+	}
+	aligned := filepath.Join(dir, "aligned.paf")
+	if code := Align([]string{"-q", prefix + ".query.fa", "-r", prefix + ".ref.fa", "-f", "paf", "-o", aligned}); code != 0 {
+		t.Fatalf("align exited with %d", code)
+	}
+
+	report := filepath.Join(dir, "report.txt")
+	if code := Eval([]string{"-a", aligned, "-t", prefix + ".truth.paf", "-e", prefix + ".events.tsv", "-o", report}); code != 0 {
+		t.Fatalf("eval exited with %d", code)
+	}
+	golden.AssertString(t, readFile(t, report))
+
+	self := filepath.Join(dir, "self.json")
+	if code := Eval([]string{"-a", prefix + ".truth.paf", "-t", prefix + ".truth.paf", "-json", "-o", self}); code != 0 {
+		t.Fatalf("eval -json exited with %d", code)
+	}
+	if !strings.Contains(readFile(t, self), `"f1": 1,`) {
+		t.Errorf("truth against itself is not perfect:\n%s", readFile(t, self))
+	}
+	if code := Eval([]string{"-a", aligned}); code != 2 {
+		t.Errorf("missing -t: exit code %d, want 2", code)
+	}
+}
+
+func TestTuneCommand(t *testing.T) {
+	dir := t.TempDir()
+	prefix := filepath.Join(dir, "sim")
+	if code := Simulate([]string{"-o", prefix, "-length", "2000", "-snp-rate", "0.02"}); code != 0 {
+		t.Fatalf("simulate exited with %d", code)
+	}
+	train := filepath.Join(dir, "train.tsv")
+	content := "# query\tref\ttruth\nsim.query.fa\tsim.ref.fa\tsim.truth.paf\nsim.query.fa\tsim.ref.fa\n"
+	if err := os.WriteFile(train, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	best, log := filepath.Join(dir, "best.json"), filepath.Join(dir, "trials.tsv")
+	if code := Tune([]string{"-train", train, "-trials", "2", "-dims", "k_shift,merge_gap", "-o", best, "-log", log}); code != 0 {
+		t.Fatalf("tune exited with %d", code)
+	}
+	golden.AssertString(t, readFile(t, log))
+	p, err := config.LoadFile(best, config.Default())
+	if err != nil {
+		t.Fatalf("best parameters do not load as a config file: %v", err)
+	}
+	if p.MinMatchLength != config.Default().MinMatchLength {
+		t.Errorf("tune changed a parameter outside the searched dimensions")
+	}
+
+	if code := Tune([]string{"-search", "anneal"}); code != 2 {
+		t.Errorf("unknown search: exit code %d, want 2", code)
+	}
+	if code := Tune([]string{"-dims", "nope"}); code != 2 {
+		t.Errorf("unknown dimension: exit code %d, want 2", code)
+	}
+}
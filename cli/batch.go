@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/aligner"
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/io"
+	"DNA-Sequence-Alignments/dna_aligner/matching"
+	"DNA-Sequence-Alignments/dna_aligner/output"
+	"bytes"
+	"flag"
+	"fmt"
+	goio "io"
+	"os"
+	"sync"
+)
+
+// batchJob is one query record waiting to be aligned; index is its position in the input.
+type batchJob struct {
+	index  int
+	record io.Record
+}
+
+// batchResult holds the formatted output of one query.
+type batchResult struct {
+	index int
+	data  []byte
+	err   error
+}
+
+// Batch implements `dnaseq batch`: align every record of a FASTA/FASTQ file against one reference.
+// Records are aligned concurrently, but results are written in input order. At most 2*jobs records
+// are held in memory at any time.
+func Batch(args []string) int {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	queryFile := fs.String("q", "", "FASTA/FASTQ file with the query records (required, - for stdin)")
+	refFile := fs.String("r", "", "reference sequence file (required)")
+	outputFile := fs.String("o", "", "output file (default stdout)")
+	format := fs.String("f", "paf", "output format: tuples, paf or sam")
+	jobs := fs.Int("j", 4, "number of queries aligned concurrently")
+	threads := fs.Int("t", 1, "worker threads inside each alignment")
+	refName := fs.String("ref-name", "", "reference name in output records (default: reference file name)")
+	maxEValue := fs.Float64("max-evalue", 0, "drop segments with an E-value above this (0 keeps all)")
+	noFallback := fs.Bool("no-fallback", false, "leave query regions without matches unaligned instead of adding fallback segments")
+	tandemCopies := fs.Bool("tandem-copies", false, "report every copy of a tandem expansion in the query as its own segment (PAF/SAM tag ci:i)")
+	verbose := fs.Bool("v", false, "print the per-query pipeline log to stderr")
+	params := addParamFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *queryFile == "" || *refFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: both -q and -r are required")
+		fs.Usage()
+		return 2
+	}
+	if !validFormat(*format) {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q\n", *format)
+		return 2
+	}
+	p, err := params.resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *jobs < 1 {
+		*jobs = 1
+	}
+	if *refName == "" {
+		*refName = fileStem(*refFile)
+	}
+
+	common.LogWriter = goio.Discard
+	if *verbose {
+		common.LogWriter = os.Stderr
+	}
+
+	ref, err := io.ReadRecord(*refFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading reference file '%s': %v\n", *refFile, err)
+		return 1
+	}
+	refSeq := ref.Seq
+
+	var queryIn goio.Reader = os.Stdin
+	if *queryFile != "-" {
+		f, err := os.Open(*queryFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening query file '%s': %v\n", *queryFile, err)
+			return 1
+		}
+		defer f.Close()
+		queryIn = f
+	}
+
+	out, closeOut, err := createOutput(*outputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer closeOut()
+	if *format == "sam" {
+		if err := output.WriteSAMHeader(out, *refName, len(refSeq), params.description(p)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			return 1
+		}
+	}
+
+	opts := aligner.Options{
+		NoFallback:   *noFallback,
+		MaxEValue:    *maxEValue,
+		Threads:      *threads,
+		TandemCopies: *tandemCopies,
+		Params:       &p,
+		Repeats:      matching.NewRepeatIndex(refSeq, p.MapQSeedK),
+	}
+	alignRecord := func(rec io.Record) ([]byte, error) {
+		res := aligner.Align(rec.Seq, refSeq, opts)
+		pair := output.Pair{QueryName: rec.Name, Query: rec.Seq, RefName: *refName, Ref: refSeq}
+		var buf bytes.Buffer
+		err := writeSegments(&buf, *format, pair, res, true)
+		return buf.Bytes(), err
+	}
+
+	written, err := runBatchPipeline(io.NewRecordReader(queryIn), out, *jobs, alignRecord)
+	fmt.Fprintf(os.Stderr, "\rAligned %d queries\n", written)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runBatchPipeline reads records, aligns them on `workers` goroutines and writes the results to out
+// in input order, reporting progress on stderr. It returns the number of records written.
+func runBatchPipeline(reader *io.RecordReader, out goio.Writer, workers int, alignRecord func(io.Record) ([]byte, error)) (int, error) {
+	jobCh := make(chan batchJob)
+	resultCh := make(chan batchResult)
+	slots := make(chan struct{}, 2*workers) // Bounds the records in flight (read but not yet written)
+	done := make(chan struct{})
+	readErr := make(chan error, 1)
+
+	// Reader: stops early when the writer gives up.
+	go func() {
+		defer close(jobCh)
+		for index := 0; ; index++ {
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				readErr <- nil
+				return
+			}
+			rec, err := reader.Next()
+			if err == goio.EOF {
+				readErr <- nil
+				return
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case jobCh <- batchJob{index: index, record: rec}:
+			case <-done:
+				readErr <- nil
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				data, err := alignRecord(job.record)
+				if err != nil {
+					err = fmt.Errorf("query '%s': %w", job.record.Name, err)
+				}
+				resultCh <- batchResult{index: job.index, data: data, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	// Writer: buffers out-of-order results until their turn comes.
+	pending := make(map[int]batchResult)
+	next := 0
+	var firstErr error
+	for res := range resultCh {
+		if firstErr != nil {
+			continue // Drain remaining results after a failure
+		}
+		pending[res.index] = res
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if r.err == nil {
+				_, r.err = out.Write(r.data)
+			}
+			if r.err != nil {
+				firstErr = r.err
+				close(done)
+				break
+			}
+			next++
+			<-slots
+			fmt.Fprintf(os.Stderr, "\rAligned %d queries", next)
+		}
+	}
+	if err := <-readErr; err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return next, firstErr
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/aligner"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/io"
+	"DNA-Sequence-Alignments/dna_aligner/output"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RunBundledDatasets aligns the query/ref pairs in the data directory and writes result<i>.txt files.
+func RunBundledDatasets() {
+	// Determine data directory. Assumes 'data' is a subdirectory where the app is run,
+	// or relative to the executable path.
+	dataDir := "data" // Default to CWD/data
+	exePath, err := os.Executable()
+	if err == nil {
+		dataDir = filepath.Join(filepath.Dir(exePath), "data")
+	}
+	// Fallback if executable path is weird or 'data' isn't there, try CWD/data again
+	if _, statErr := os.Stat(dataDir); os.IsNotExist(statErr) {
+		cwd, _ := os.Getwd()
+		dataDir = filepath.Join(cwd, "data")
+	}
+
+	fmt.Printf("Using data directory: %s\n", dataDir)
+	if _, statErr := os.Stat(dataDir); os.IsNotExist(statErr) {
+		fmt.Printf("Error: Data directory '%s' not found. Please create it and place query/ref files.\n", dataDir)
+		fmt.Println("Expected files like 'query1.txt', 'ref1.txt', etc., inside the data directory.")
+		return
+	}
+
+	// FindAlignment runs with the default parameters.
+	params := config.Default()
+	for i := 1; i <= 2; i++ { // Process dataset 1 and 2 as in Python's main
+		queryFileName := fmt.Sprintf("query%d.txt", i)
+		refFileName := fmt.Sprintf("ref%d.txt", i)
+		queryFile := filepath.Join(dataDir, queryFileName)
+		refFile := filepath.Join(dataDir, refFileName)
+		outputFile := fmt.Sprintf("result%d.txt", i) // Output in CWD
+
+		fmt.Printf("\nProcessing dataset %d (Query: %s, Ref: %s)...\n", i, queryFileName, refFileName)
+
+		query, err := io.ReadRecord(queryFile)
+		if err != nil {
+			fmt.Printf("Error reading query file '%s': %v\n", queryFile, err)
+			continue
+		}
+		ref, err := io.ReadRecord(refFile)
+		if err != nil {
+			fmt.Printf("Error reading reference file '%s': %v\n", refFile, err)
+			continue
+		}
+		querySeq, refSeq := query.Seq, ref.Seq
+
+		fmt.Printf("Query length: %d, Reference length: %d\n", len(querySeq), len(refSeq))
+
+		startTime := time.Now()
+
+		// The aligner.FindAlignment function uses 0 for minMatchLenUser to trigger default/adaptive logic.
+		alignmentResultSegments := aligner.FindAlignment(querySeq, refSeq, 0)
+
+		duration := time.Since(startTime)
+		fmt.Printf("Time taken for dataset %d: %.2f seconds\n", i, duration.Seconds())
+		fmt.Printf("Found %d matching regions for dataset %d\n", len(alignmentResultSegments), i)
+
+		outputString := output.FormatTuples(alignmentResultSegments)
+		err = os.WriteFile(outputFile, []byte(outputString), 0644)
+		if err != nil {
+			fmt.Printf("Error writing output file '%s': %v\n", outputFile, err)
+			continue
+		}
+		fmt.Printf("Results for dataset %d written to '%s'\n", i, outputFile)
+
+		statsFile := fmt.Sprintf("result%d.stats.json", i)
+		if err := writeSummary(statsFile, &params, len(querySeq), len(refSeq), alignmentResultSegments); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		fmt.Printf("Summary report for dataset %d written to '%s'\n", i, statsFile)
+	}
+}
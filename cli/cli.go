@@ -0,0 +1,64 @@
+// Package cli implements the subcommands of the dnaseq binary. Every command takes its
+// arguments without the command name and returns the process exit code: 0 on success, 2 for
+// usage errors and 1 for any other failure.
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Command is one subcommand.
+type Command struct {
+	Name    string
+	Summary string
+	Run     func(args []string) int
+}
+
+// Commands lists the subcommands in the order of the usage text.
+var Commands = []Command{
+	{"align", "align one query against one reference", Align},
+	{"batch", "align every record of a FASTA/FASTQ file against one reference", Batch},
+	{"variants", "report small variants between query and reference as VCF", Variants},
+	{"dups", "find tandem and interspersed duplications of reference segments in a query", Dups},
+	{"index", "write the .fai index of a FASTA file", Index},
+	{"stats", "report length, GC content and N count of every record", Stats},
+	{"simulate", "simulate a query with known structural variation", Simulate},
+	{"eval", "compare an alignment with a truth mapping", Eval},
+	{"tune", "search the aligner parameters on a training set", Tune},
+}
+
+// Main runs the command named by args[0] with the remaining arguments.
+func Main(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	for _, c := range Commands {
+		if c.Name == args[0] {
+			return c.Run(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q. Available commands: %s\n", args[0], strings.Join(commandNames(), ", "))
+	return 2
+}
+
+func commandNames() []string {
+	names := make([]string, len(Commands))
+	for i, c := range Commands {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: dnaseq <command> [flags]\n\nCommands:")
+	for _, c := range Commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", c.Name, c.Summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'dnaseq <command> -h' for the flags of a command.")
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testFASTA = ">chr1 first\nACGTACGT\nGGCCNN\n>chr2\nAT\n"
+
+func TestIndexCommand(t *testing.T) {
+	dir := t.TempDir()
+	fasta := filepath.Join(dir, "genome.fa")
+	if err := os.WriteFile(fasta, []byte(testFASTA), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := Index([]string{fasta}); code != 0 {
+		t.Fatalf("index exited with %d", code)
+	}
+	golden.AssertString(t, readFile(t, fasta+".fai"))
+
+	out := filepath.Join(dir, "other.fai")
+	if code := Index([]string{"-o", out, fasta}); code != 0 {
+		t.Fatalf("index -o exited with %d", code)
+	}
+	if readFile(t, out) != readFile(t, fasta+".fai") {
+		t.Error("-o wrote a different index")
+	}
+
+	ragged := filepath.Join(dir, "ragged.fa")
+	if err := os.WriteFile(ragged, []byte(">r\nACG\nACGT\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = stderr }()
+	if code := Index([]string{ragged}); code != 1 {
+		t.Errorf("ragged lines: exit code %d, want 1", code)
+	}
+	if code := Index(nil); code != 2 {
+		t.Errorf("no file: exit code %d, want 2", code)
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	dir := t.TempDir()
+	fasta := filepath.Join(dir, "genome.fa")
+	plain := filepath.Join(dir, "plain.txt")
+	if err := os.WriteFile(fasta, []byte(testFASTA), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(plain, []byte("ggcc\nnA\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	for _, format := range []string{"tsv", "json"} {
+		out := filepath.Join(dir, "stats."+format)
+		if code := Stats([]string{"-f", format, "-o", out, fasta, plain}); code != 0 {
+			t.Fatalf("stats -f %s exited with %d", format, code)
+		}
+		fmt.Fprintf(&sb, "== %s\n%s", format, strings.ReplaceAll(readFile(t, out), dir+string(filepath.Separator), ""))
+	}
+	golden.AssertString(t, sb.String())
+
+	stderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = stderr }()
+	if code := Stats([]string{"-f", "csv", fasta}); code != 2 {
+		t.Errorf("unknown format: exit code %d, want 2", code)
+	}
+	if code := Stats([]string{filepath.Join(dir, "missing.fa")}); code != 1 {
+		t.Errorf("missing file: exit code %d, want 1", code)
+	}
+}
+
+// TestCommandDispatch runs subcommands by name, as the dnaseq binary does.
+func TestCommandDispatch(t *testing.T) {
+	dir := t.TempDir()
+	fasta := filepath.Join(dir, "genome.fa")
+	if err := os.WriteFile(fasta, []byte(testFASTA), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := Main([]string{"index", fasta}); code != 0 {
+		t.Fatalf("index exited with %d", code)
+	}
+	if _, err := os.Stat(fasta + ".fai"); err != nil {
+		t.Error(err)
+	}
+	stderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = stderr }()
+	for _, args := range [][]string{nil, {"nope"}, {"dups"}} {
+		if code := Main(args); code != 2 {
+			t.Errorf("%q: exit code %d, want 2", args, code)
+		}
+	}
+}
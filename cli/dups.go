@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/io"
+	"DNA-Sequence-Alignments/dup_identification/dups"
+	"flag"
+	"fmt"
+	goio "io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Dups implements `dnaseq dups`: find the reference segments duplicated in a query, as tandem
+// repeats or interspersed duplications.
+func Dups(args []string) int {
+	fs := flag.NewFlagSet("dups", flag.ContinueOnError)
+	queryFile := fs.String("q", "", "query sequence file (required)")
+	refFile := fs.String("r", "", "reference sequence file (required)")
+	outputFile := fs.String("o", "", "output file (default stdout)")
+	format := fs.String("f", "md", "output format: "+strings.Join(dupsFormats, ", "))
+	minUnit := fs.Int("min-unit", dups.DefaultMinUnitLength, "shortest repeat unit reported; shorter reference matches are skipped")
+	mode := fs.String("mode", "tandem", "report tandem duplicates or interspersed duplications (tandem or interspersed)")
+	minLength := fs.Int("min-length", 20, "shortest reference region reported in interspersed mode")
+	maxEdits := fs.Int("max-edits", 0, "mismatches and indels tolerated per copy of a tandem unit (0 counts exact copies only)")
+	minPartial := fs.Int("min-partial", 0, "shortest trailing partial copy reported when -max-edits is set (0: half the unit)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		return 2
+	}
+	if *queryFile == "" || *refFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: both -q and -r are required")
+		fs.Usage()
+		return 2
+	}
+	if *mode != "tandem" && *mode != "interspersed" {
+		fmt.Fprintf(os.Stderr, "Error: unknown mode %q\n", *mode)
+		return 2
+	}
+	if *maxEdits < 0 || *minPartial < 0 || *minUnit < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -max-edits, -min-partial and -min-unit must not be negative\n")
+		return 2
+	}
+	if !slices.Contains(dupsFormats, *format) {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q (available: %s)\n", *format, strings.Join(dupsFormats, ", "))
+		return 2
+	}
+
+	ref, err := io.ReadRecord(*refFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: reading reference: %v\n", err)
+		return 1
+	}
+	query, err := io.ReadRecord(*queryFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: reading query: %v\n", err)
+		return 1
+	}
+	res, err := dups.Analyze(query.Seq, ref.Seq, dups.Options{MaxEdits: *maxEdits, MinPartial: *minPartial, MinUnitLength: *minUnit})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	write := func(w goio.Writer) error { return writeResults(w, *format, ref.Name, res) }
+	if *mode == "interspersed" {
+		regions := dups.FindInterspersed(ref.Seq, res, *minLength)
+		write = func(w goio.Writer) error { return writeInterspersedResults(w, *format, ref.Name, regions) }
+	}
+	if *outputFile == "" {
+		err = write(os.Stdout)
+	} else {
+		err = writeFile(*outputFile, write)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dup_identification/dups"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// dupsFormats lists the output formats accepted by `dups -f`.
+var dupsFormats = []string{"md", "tsv", "json", "bed"}
+
+// writeResults writes the duplicates in the given format. refName names the reference
+// sequence in BED output.
+func writeResults(w io.Writer, format, refName string, results []dups.Duplicate) error {
+	switch format {
+	case "md":
+		return writeMarkdown(w, results)
+	case "tsv":
+		return writeTSV(w, results)
+	case "json":
+		return writeJSON(w, results)
+	case "bed":
+		return writeBED(w, refName, results)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// writeInterspersedResults writes the interspersed duplications in the given format. refName
+// names the reference sequence in BED output.
+func writeInterspersedResults(w io.Writer, format, refName string, regions []dups.Interspersed) error {
+	switch format {
+	case "md":
+		return writeInterspersedMarkdown(w, regions)
+	case "tsv":
+		return writeInterspersedTSV(w, regions)
+	case "json":
+		if regions == nil {
+			regions = []dups.Interspersed{}
+		}
+		return writeJSONValue(w, regions)
+	case "bed":
+		return writeInterspersedBED(w, refName, regions)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+func writeMarkdown(w io.Writer, results []dups.Duplicate) error {
+	fmt.Fprintln(w, "Duplicate Identification Results")
+	fmt.Fprintln(w, "|   Query Span    |    Ref Span     |   Repeat Size   |   Repeat Count   |   Inverse   |   Ref Occurrences   |   Copy Identities   |")
+	fmt.Fprintln(w, "|-----------------|-----------------|-----------------|------------------|-------------|---------------------|---------------------|")
+
+	for _, entry := range results {
+		invertedStr := "Yes"
+		if !entry.IsInverted {
+			invertedStr = "No"
+		}
+		if _, err := fmt.Fprintf(w, "|   %-13s |   %-13s |   %-13d |   %-14d |   %-9s |   %-17s |   %-17s |\n",
+			span(entry.QueryStart, entry.QueryEnd), span(entry.RefStart, entry.RefEnd),
+			entry.Length, entry.Count, invertedStr, joinInts(entry.RefOccurrences), copyIdentities(entry.Copies)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTSV writes one line per duplicate with 0-based, end-exclusive positions.
+// partial_length is the query length of a trailing partial copy (0 without one).
+func writeTSV(w io.Writer, results []dups.Duplicate) error {
+	if _, err := fmt.Fprintln(w, "#query_start\tquery_end\tref_start\tref_end\tlength\tcount\tpartial_length\tinverted\tref_occurrences\tcopy_identities"); err != nil {
+		return err
+	}
+	for _, d := range results {
+		partial := 0
+		if n := len(d.Copies); n > 0 && d.Copies[n-1].Partial {
+			partial = d.Copies[n-1].QueryEnd - d.Copies[n-1].QueryStart
+		}
+		if _, err := fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%d\t%d\t%v\t%s\t%s\n", d.QueryStart, d.QueryEnd, d.RefStart, d.RefEnd,
+			d.Length, d.Count, partial, d.IsInverted, joinInts(d.RefOccurrences), copyIdentities(d.Copies)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func span(start, end int) string {
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+// copyIdentities lists the identity of every copy; a partial copy is marked with '*'.
+func copyIdentities(copies []dups.Copy) string {
+	parts := make([]string, len(copies))
+	for i, c := range copies {
+		parts[i] = strconv.FormatFloat(c.Identity, 'f', 3, 64)
+		if c.Partial {
+			parts[i] += "*"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func writeJSON(w io.Writer, results []dups.Duplicate) error {
+	if results == nil {
+		results = []dups.Duplicate{}
+	}
+	return writeJSONValue(w, results)
+}
+
+func writeJSONValue(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// writeBED writes the matched reference interval of every duplicated unit as BED6. The name
+// records the copy count and the strand is '-' for inverted copies.
+func writeBED(w io.Writer, refName string, results []dups.Duplicate) error {
+	for _, d := range results {
+		if d.RefStart < 0 {
+			continue
+		}
+		strand := "+"
+		if d.IsInverted {
+			strand = "-"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%d\t%d\tcopies=%d\t0\t%s\n", refName, d.RefStart, d.RefEnd, d.Count, strand); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeInterspersedMarkdown(w io.Writer, regions []dups.Interspersed) error {
+	fmt.Fprintln(w, "Interspersed Duplication Results")
+	fmt.Fprintln(w, "|    Ref Span     |   Query Copies   |   Ref Copies   |   Separated   |   Query Loci   |")
+	fmt.Fprintln(w, "|-----------------|------------------|----------------|---------------|----------------|")
+
+	for _, r := range regions {
+		separatedStr := "Yes"
+		if !r.Separated {
+			separatedStr = "No"
+		}
+		if _, err := fmt.Fprintf(w, "|   %-13s |   %-14d |   %-12d |   %-11s |   %s |\n",
+			span(r.RefStart, r.RefEnd), r.QueryCopies, r.RefCopies, separatedStr, formatLoci(r.Loci)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeInterspersedTSV writes one line per region with 0-based, end-exclusive positions.
+func writeInterspersedTSV(w io.Writer, regions []dups.Interspersed) error {
+	if _, err := fmt.Fprintln(w, "#ref_start\tref_end\tquery_copies\tref_copies\tseparated\tquery_loci"); err != nil {
+		return err
+	}
+	for _, r := range regions {
+		if _, err := fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%v\t%s\n", r.RefStart, r.RefEnd, r.QueryCopies, r.RefCopies, r.Separated, formatLoci(r.Loci)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeInterspersedBED writes every region as BED6, named after its copy numbers in the
+// query and the reference.
+func writeInterspersedBED(w io.Writer, refName string, regions []dups.Interspersed) error {
+	for _, r := range regions {
+		if _, err := fmt.Fprintf(w, "%s\t%d\t%d\tquery=%d,ref=%d\t0\t.\n", refName, r.RefStart, r.RefEnd, r.QueryCopies, r.RefCopies); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatLoci lists query loci as start-end followed by the strand of the copy.
+func formatLoci(loci []dups.Locus) string {
+	parts := make([]string, len(loci))
+	for i, l := range loci {
+		strand := "+"
+		if l.IsInverted {
+			strand = "-"
+		}
+		parts[i] = span(l.QueryStart, l.QueryEnd) + strand
+	}
+	return strings.Join(parts, ",")
+}
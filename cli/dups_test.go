@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDupsFormats(t *testing.T) {
+	dir := t.TempDir()
+	ref := "ACGTTGCAAGGCTTACCGATGCATTGACCA"
+	queryFile := filepath.Join(dir, "query.fa")
+	refFile := filepath.Join(dir, "ref.fa")
+	if err := os.WriteFile(refFile, []byte(">chrT description\n"+ref[:15]+"\n"+ref[15:]+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	query := ref[:20] + ref[10:20] + ref[10:20] + ref[20:]
+	if err := os.WriteFile(queryFile, []byte(">q\n"+strings.ToLower(query)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	for _, mode := range []string{"tandem", "interspersed"} {
+		for _, format := range dupsFormats {
+			out := filepath.Join(dir, "out."+format)
+			if code := Dups([]string{"-r", refFile, "-q", queryFile, "-o", out, "-f", format, "-mode", mode, "-min-length", "5"}); code != 0 {
+				t.Fatalf("-mode %s -f %s exited with %d", mode, format, code)
+			}
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprintf(&sb, "== %s %s\n%s", mode, format, data)
+		}
+	}
+	golden.AssertString(t, sb.String())
+}
+
+func TestDupsErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.txt")
+	seq := filepath.Join(dir, "seq.txt")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(seq, []byte("ACGTACGT\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"missing -q", []string{"-r", seq}, 2},
+		{"unknown format", []string{"-f", "xml"}, 2},
+		{"unknown mode", []string{"-mode", "nested"}, 2},
+		{"unknown flag", []string{"-x"}, 2},
+		{"positional argument", []string{"extra"}, 2},
+		{"missing reference", []string{"-r", filepath.Join(dir, "missing.txt")}, 1},
+		{"empty query", []string{"-q", empty}, 1},
+		{"unwritable output", []string{"-o", filepath.Join(dir, "missing", "out.md")}, 1},
+	}
+	stderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = stderr }()
+	for _, c := range cases {
+		args := c.args
+		if c.name != "missing -q" {
+			args = append([]string{"-r", seq, "-q", seq}, args...)
+		}
+		if code := Dups(args); code != c.want {
+			t.Errorf("%s: exit code %d, want %d", c.name, code, c.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/eval"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Eval implements `dnaseq eval`: compare an alignment (tuples or PAF) with a truth mapping.
+func Eval(args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	alignFile := fs.String("a", "", "alignment to evaluate, tuples or PAF (required)")
+	truthFile := fs.String("t", "", "truth mapping, tuples or PAF (required)")
+	eventsFile := fs.String("e", "", "events TSV written by `simulate`, for per-event-type sensitivity")
+	outputFile := fs.String("o", "", "report file (default stdout)")
+	tolerance := fs.Int("tol", eval.DefaultTolerance, "largest position or breakpoint error (bases) still counted as correct")
+	asJSON := fs.Bool("json", false, "write the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *alignFile == "" || *truthFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: both -a and -t are required")
+		fs.Usage()
+		return 2
+	}
+
+	pred, err := readMapping(*alignFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	truth, err := readMapping(*truthFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	var events []simulate.Event
+	if *eventsFile != "" {
+		f, err := os.Open(*eventsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		events, err = simulate.ReadEvents(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading events file '%s': %v\n", *eventsFile, err)
+			return 1
+		}
+	}
+
+	report := eval.Evaluate(pred, truth, events, eval.Options{Tolerance: *tolerance})
+
+	out, closeOut, err := createOutput(*outputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer closeOut()
+	if *asJSON {
+		err = eval.WriteJSON(out, report)
+	} else {
+		err = eval.WriteText(out, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readMapping reads an alignment or truth file in tuples or PAF format.
+func readMapping(path string) (eval.Mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return eval.Mapping{}, err
+	}
+	defer f.Close()
+	m, err := eval.ReadMapping(f)
+	if err != nil {
+		return m, fmt.Errorf("reading '%s': %w", path, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/io"
+	"flag"
+	"fmt"
+	goio "io"
+	"os"
+)
+
+// Index implements `dnaseq index`: write the samtools-compatible index (.fai) of a FASTA file.
+func Index(args []string) int {
+	fs := flag.NewFlagSet("index", flag.ContinueOnError)
+	outputFile := fs.String("o", "", "index file (default <file>.fai)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: index [-o file.fai] file.fa")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: exactly one FASTA file is required")
+		fs.Usage()
+		return 2
+	}
+	fastaFile := fs.Arg(0)
+	if *outputFile == "" {
+		*outputFile = fastaFile + ".fai"
+	}
+
+	f, err := os.Open(fastaFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	entries, err := io.BuildFAI(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: indexing '%s': %v\n", fastaFile, err)
+		return 1
+	}
+	if err := writeFile(*outputFile, func(w goio.Writer) error { return io.WriteFAI(w, entries) }); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
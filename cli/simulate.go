@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/io"
+	"DNA-Sequence-Alignments/dna_aligner/output"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"flag"
+	"fmt"
+	goio "io"
+	"math/rand"
+	"os"
+)
+
+// Simulate implements `dnaseq simulate`: mutate a random or given reference and write the
+// query together with the true query-to-reference mapping.
+//
+// Files written, for -o PREFIX: PREFIX.query.fa, PREFIX.ref.fa (random reference only),
+// PREFIX.truth.txt or PREFIX.truth.paf (the truth, as the aligner would report it) and
+// PREFIX.events.tsv (every applied mutation).
+func Simulate(args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	refFile := fs.String("r", "", "reference to mutate (default: a random reference of -length bases)")
+	prefix := fs.String("o", "sim", "output file prefix")
+	format := fs.String("f", "paf", "truth format: tuples or paf (paf also records the strand)")
+	seed := fs.Int64("seed", 1, "random seed")
+	length := fs.Int("length", 10000, "length of the random reference")
+	gc := fs.Float64("gc", 0.45, "GC fraction of the random reference")
+	snpRate := fs.Float64("snp-rate", 0.005, "substitutions per base")
+	indelRate := fs.Float64("indel-rate", 0.0005, "indels per base")
+	indelMax := fs.Int("indel-max", 10, "maximum indel length")
+	indelMean := fs.Float64("indel-mean", 0, "mean indel length of a geometric distribution (0: uniform up to -indel-max)")
+	inversions := fs.Int("inversions", 0, "number of inversions")
+	inversionLen := fs.Int("inversion-len", 1000, "inversion length")
+	tandemDups := fs.Int("tandem-dups", 0, "number of tandem duplications")
+	interspersedDups := fs.Int("interspersed-dups", 0, "number of interspersed duplications")
+	dupLen := fs.Int("dup-len", 500, "duplication length")
+	translocations := fs.Int("translocations", 0, "number of translocations")
+	translocationLen := fs.Int("translocation-len", 1000, "translocation length")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "tuples" && *format != "paf" {
+		fmt.Fprintf(os.Stderr, "Error: unknown truth format %q\n", *format)
+		return 2
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	refName := "sim_ref"
+	var ref string
+	if *refFile != "" {
+		rec, err := io.ReadRecord(*refFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading reference file '%s': %v\n", *refFile, err)
+			return 1
+		}
+		ref = rec.Seq
+		refName = fileStem(*refFile)
+	} else {
+		if *length <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: -length must be positive")
+			return 2
+		}
+		ref = simulate.RandomSequence(rng, *length, *gc)
+	}
+
+	res := simulate.Mutate(rng, ref, simulate.Model{
+		SNPRate:                  *snpRate,
+		IndelRate:                *indelRate,
+		MaxIndelLength:           *indelMax,
+		IndelLengthMean:          *indelMean,
+		Inversions:               *inversions,
+		InversionLength:          *inversionLen,
+		Duplications:             *tandemDups,
+		DuplicationLength:        *dupLen,
+		InterspersedDuplications: *interspersedDups,
+		Translocations:           *translocations,
+		TranslocationLength:      *translocationLen,
+	})
+
+	pair := output.Pair{QueryName: "sim_query", Query: res.Query, RefName: refName, Ref: ref}
+	truthFile := *prefix + ".truth.txt"
+	if *format == "paf" {
+		truthFile = *prefix + ".truth.paf"
+	}
+	truth, alignments := res.Segments(), res.Alignments()
+	mapqMax := config.Default().MapQMax
+	for i := range truth {
+		truth[i].MapQ = mapqMax
+		truth[i].Identity = alignments[i].Identity()
+	}
+	writeTruth := func(w goio.Writer) error {
+		if *format == "paf" {
+			return output.WritePAF(w, pair, truth, alignments)
+		}
+		_, err := fmt.Fprintln(w, output.FormatTuples(truth))
+		return err
+	}
+
+	err := writeFile(*prefix+".query.fa", func(w goio.Writer) error { return io.WriteFASTA(w, pair.QueryName, res.Query) })
+	if err == nil && *refFile == "" {
+		err = writeFile(*prefix+".ref.fa", func(w goio.Writer) error { return io.WriteFASTA(w, refName, ref) })
+	}
+	if err == nil {
+		err = writeFile(truthFile, writeTruth)
+	}
+	if err == nil {
+		err = writeFile(*prefix+".events.tsv", func(w goio.Writer) error { return simulate.WriteEvents(w, res.Events) })
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Simulated a %d bp query from a %d bp reference with %d events\n", len(res.Query), len(ref), len(res.Events))
+	return 0
+}
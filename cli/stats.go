@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/io"
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"flag"
+	"fmt"
+	goio "io"
+	"os"
+	"strings"
+)
+
+// recordStats summarizes one sequence record.
+type recordStats struct {
+	File      string  `json:"file"`
+	Name      string  `json:"name"`
+	Length    int     `json:"length"`
+	GCContent float64 `json:"gc_content"` // Fraction of all bases, N included
+	NCount    int     `json:"n_count"`
+}
+
+// Stats implements `dnaseq stats`: report the length, GC content and N count of every record of
+// one or more sequence files.
+func Stats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	outputFile := fs.String("o", "", "output file (default stdout)")
+	format := fs.String("f", "tsv", "output format: tsv or json")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: stats [-f tsv|json] [-o file] file...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: at least one sequence file is required")
+		fs.Usage()
+		return 2
+	}
+	if *format != "tsv" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q\n", *format)
+		return 2
+	}
+
+	stats := []recordStats{}
+	for _, path := range fs.Args() {
+		records, err := io.ReadRecords(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, rec := range records {
+			stats = append(stats, recordStats{
+				File:      path,
+				Name:      rec.Name,
+				Length:    len(rec.Seq),
+				GCContent: sequence.CalculateGCContent(rec.Seq),
+				NCount:    strings.Count(strings.ToUpper(rec.Seq), "N"),
+			})
+		}
+	}
+
+	write := func(w goio.Writer) error { return writeStatsTSV(w, stats) }
+	if *format == "json" {
+		write = func(w goio.Writer) error { return writeJSONValue(w, stats) }
+	}
+	var err error
+	if *outputFile == "" {
+		err = write(os.Stdout)
+	} else {
+		err = writeFile(*outputFile, write)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func writeStatsTSV(w goio.Writer, stats []recordStats) error {
+	if _, err := fmt.Fprintln(w, "file\tname\tlength\tgc_content\tn_count"); err != nil {
+		return err
+	}
+	for _, s := range stats {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%d\t%.4f\t%d\n", s.File, s.Name, s.Length, s.GCContent, s.NCount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/tune"
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	goio "io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tune implements `dnaseq tune`: search the aligner parameters for the set that aligns
+// a training set best and write it as a config file for -config.
+func Tune(args []string) int {
+	fs := flag.NewFlagSet("tune", flag.ContinueOnError)
+	trainFile := fs.String("train", "", "training set: TSV lines of query, reference and optional truth (tuples or PAF) files; default: simulated scenarios")
+	simSeeds := fs.Int("sim", 1, "seeds per simulated scenario when no -train file is given")
+	search := fs.String("search", "random", "search strategy: random or grid")
+	trials := fs.Int("trials", 20, "random parameter sets tried after the base preset")
+	seed := fs.Int64("seed", 1, "seed for the random search")
+	dims := fs.String("dims", "", "comma-separated dimensions to search (default all: "+dimensionNames()+")")
+	threads := fs.Int("t", 1, "training examples aligned concurrently")
+	outputFile := fs.String("o", "", "file receiving the best parameters as JSON (default stdout)")
+	logFile := fs.String("log", "", "TSV file receiving every trial (default stderr)")
+	params := addParamFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *search != "random" && *search != "grid" {
+		fmt.Fprintf(os.Stderr, "Error: unknown search strategy %q\n", *search)
+		return 2
+	}
+	opts := tune.Options{Grid: *search == "grid", Trials: *trials, Seed: *seed, Threads: *threads}
+	if *dims != "" {
+		selected, err := tune.SelectDimensions(strings.Split(*dims, ","))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		opts.Dimensions = selected
+	}
+	base, err := params.resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	common.LogWriter = goio.Discard
+
+	var examples []tune.Example
+	if *trainFile != "" {
+		var err error
+		if examples, err = readTrainingSet(*trainFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	} else {
+		examples = tune.SimulatedExamples(*simSeeds)
+	}
+
+	var logOut goio.Writer = os.Stderr
+	if *logFile != "" {
+		f, err := os.Create(*logFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: creating log file '%s': %v\n", *logFile, err)
+			return 1
+		}
+		defer f.Close()
+		logOut = f
+	}
+	fmt.Fprintf(logOut, "#trial\tscore\tsettings\n")
+	opts.Progress = func(t tune.Trial) {
+		settings := strings.Join(t.Settings, " ")
+		if settings == "" {
+			settings = "base"
+		}
+		fmt.Fprintf(logOut, "%d\t%.4f\t%s\n", t.Index, t.Score, settings)
+	}
+
+	best, err := tune.Search(base, examples, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Best: trial %d, score %.4f over %d examples\n", best.Index, best.Score, len(examples))
+
+	data, err := json.MarshalIndent(best.Params, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	out, closeOut, err := createOutput(*outputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer closeOut()
+	if _, err := fmt.Fprintf(out, "%s\n", data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// dimensionNames lists the tuning dimensions for the usage message.
+func dimensionNames() string {
+	var names []string
+	for _, d := range tune.Space() {
+		names = append(names, d.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// readTrainingSet reads a TSV file of "query<TAB>reference[<TAB>truth]" lines. Relative paths
+// are resolved against the directory of the file; '#' starts a comment line.
+func readTrainingSet(path string) ([]tune.Example, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening training set '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	var examples []tune.Example
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("%s:%d: expected query, reference and optional truth columns", path, line)
+		}
+		querySeq, refSeq, err := readSequencePair(resolve(fields[0]), resolve(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		ex := tune.Example{Name: fileStem(fields[0]), Query: querySeq, Ref: refSeq}
+		if len(fields) == 3 && fields[2] != "" {
+			truth, err := readMapping(resolve(fields[2]))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			ex.Truth = &truth
+		}
+		examples = append(examples, ex)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading training set '%s': %w", path, err)
+	}
+	return examples, nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/aligner"
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/variants"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Variants implements `dnaseq variants`: align query to ref and report small variants as VCF.
+func Variants(args []string) int {
+	fs := flag.NewFlagSet("variants", flag.ContinueOnError)
+	queryFile := fs.String("q", "", "query sequence file (required)")
+	refFile := fs.String("r", "", "reference sequence file (required)")
+	outputFile := fs.String("o", "", "output VCF file (default stdout)")
+	refName := fs.String("ref-name", "", "contig name for the CHROM column (default: reference file name)")
+	sampleName := fs.String("sample", "", "sample column name (default: query file name)")
+	threads := fs.Int("t", 1, "number of worker threads")
+	maxEValue := fs.Float64("max-evalue", 0, "drop segments with an E-value above this (0 keeps all)")
+	noFallback := fs.Bool("no-fallback", false, "leave query regions without matches unaligned instead of adding fallback segments")
+	params := addParamFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *queryFile == "" || *refFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: both -q and -r are required")
+		fs.Usage()
+		return 2
+	}
+	if *refName == "" {
+		*refName = fileStem(*refFile)
+	}
+	if *sampleName == "" {
+		*sampleName = fileStem(*queryFile)
+	}
+
+	// Keep stdout clean for the VCF.
+	common.LogWriter = os.Stderr
+
+	p, err := params.resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	querySeq, refSeq, err := readSequencePair(*queryFile, *refFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	res := aligner.Align(querySeq, refSeq, aligner.Options{NoFallback: *noFallback, MaxEValue: *maxEValue, Threads: *threads, Params: &p})
+	calls := variants.Call(querySeq, refSeq, res.Segments, res.Alignments)
+	fmt.Fprintf(os.Stderr, "Called %d variants from %d segments\n", len(calls), len(res.Segments))
+
+	out, closeOut, err := createOutput(*outputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer closeOut()
+
+	header := variants.VCFHeader{
+		RefName:    *refName,
+		RefLength:  len(refSeq),
+		SampleName: *sampleName,
+		Source:     "dna_aligner",
+		Meta:       []string{"dna_alignerConfig=" + params.description(p)},
+	}
+	if err := variants.WriteVCF(out, header, calls); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing VCF: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,12 @@
+// Command dnaseq aligns DNA sequences and analyzes their duplications; see cli.Commands for the
+// subcommands.
+package main
+
+import (
+	"DNA-Sequence-Alignments/cli"
+	"os"
+)
+
+func main() {
+	os.Exit(cli.Main(os.Args[1:]))
+}
@@ -6,90 +6,161 @@ import (
 	"DNA-Sequence-Alignments/dna_aligner/graph"
 	"DNA-Sequence-Alignments/dna_aligner/matching"
 	"DNA-Sequence-Alignments/dna_aligner/merging"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/parallel"
 	"DNA-Sequence-Alignments/dna_aligner/regions"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
 	"DNA-Sequence-Alignments/dna_aligner/sequence"
-	"fmt"
 	"math"
 	"sort"
 )
 
+// Options controls FindAlignmentWithOptions. The zero value selects the default behaviour.
+type Options struct {
+	MinMatchLength int  // Minimum anchor length; 0 selects it adaptively
+	NoFallback     bool // Leave query regions without any match unaligned instead of adding fallback segments
+	Threads        int  // Worker goroutines for independent searches; <= 1 runs sequentially
+
+	// MaxEValue drops final segments whose E-value exceeds it; 0 keeps every segment.
+	MaxEValue float64
+
+	// TandemCopies reports every copy of a tandem expansion in the query (a reference unit
+	// repeated more often than in the reference) as its own segment, all pointing to the unit's
+	// reference interval with a copy index. Overlap resolution otherwise keeps a single copy.
+	TandemCopies bool
+
+	// Params are the aligner parameters; nil selects config.Default().
+	Params *config.Params
+
+	// Repeats is a prebuilt matching.NewRepeatIndex(ref, Params.MapQSeedK), so callers aligning
+	// many queries to one reference build it only once. It is built on demand when nil.
+	Repeats *matching.RepeatIndex
+}
+
+// Result holds the segments of an alignment, sorted with common.SegmentLess, and the base-level
+// alignment of each: Alignments[i] belongs to Segments[i].
+type Result struct {
+	Segments   []common.Segment
+	Alignments []pairwise.Alignment
+}
+
 // FindAlignment is the main alignment function.
 // Returns a slice of Segments (q_start, q_end, r_start, r_end) inclusive and sorted.
 func FindAlignment(query, ref string, minMatchLenUser int) []common.Segment {
+	return FindAlignmentWithOptions(query, ref, Options{MinMatchLength: minMatchLenUser})
+}
+
+// FindAlignmentWithOptions is FindAlignment with explicit options.
+func FindAlignmentWithOptions(query, ref string, opts Options) []common.Segment {
+	return Align(query, ref, opts).Segments
+}
+
+// Align is FindAlignmentWithOptions returning the base-level alignments of the segments too, so
+// callers writing PAF, SAM or variants do not align the segments again.
+func Align(query, ref string, opts Options) Result {
+	minMatchLenUser := opts.MinMatchLength
 	queryLen := len(query)
 	refLen := len(ref)
 	if queryLen == 0 || refLen == 0 {
-		return []common.Segment{}
+		return Result{Segments: []common.Segment{}}
+	}
+
+	p := opts.Params
+	if p == nil {
+		defaults := config.Default()
+		p = &defaults
 	}
 
 	// --- Adaptive parameter selection (from Python logic) ---
 	var kValuesToTry []int
 	currentMinMatchLength := minMatchLenUser
 	if currentMinMatchLength == 0 {
-		currentMinMatchLength = config.MinMatchLength
+		currentMinMatchLength = p.MinMatchLength
 	}
-	currentStride := config.DefaultStride       // Base default, may be overridden
-	currentMaxErrors := config.DefaultMaxErrors // Base default
+	currentStride := p.DefaultStride       // Base default, may be overridden
+	currentMaxErrors := p.DefaultMaxErrors // Base default
 
 	gcContent := sequence.CalculateGCContent(query)
-	fmt.Printf("GC content: %.4f\n", gcContent)
+	common.Logf("GC content: %.4f\n", gcContent)
 	seqLengthConsidered := int(math.Min(float64(queryLen), float64(refLen)))
 
-	if seqLengthConsidered < config.VeryShortSeqThreshold {
-		kValuesToTry = config.VeryShortSeqKValues
-		currentMinMatchLength = config.VeryShortSeqMinMatchLength
-		currentStride = config.VeryShortSeqStride
-		currentMaxErrors = config.HighGCMaxErrors
+	if seqLengthConsidered < p.VeryShortSeqThreshold {
+		kValuesToTry = p.VeryShortSeqKValues
+		currentMinMatchLength = p.VeryShortSeqMinMatchLength
+		currentStride = p.VeryShortSeqStride
+		currentMaxErrors = p.HighGCMaxErrors
 	} else { // Not "very short"
-		if seqLengthConsidered < config.ShortSeqThreshold { // "short"
-			if gcContent < config.LowGCThreshold {
-				kValuesToTry = config.LowGCKValues
-				currentMaxErrors = config.LowGCMaxErrors
-			} else if gcContent < config.HighGCThreshold {
-				kValuesToTry = config.MedGCKValues
-				currentMaxErrors = config.LowGCMaxErrors + 1
+		if seqLengthConsidered < p.ShortSeqThreshold { // "short"
+			if gcContent < p.LowGCThreshold {
+				kValuesToTry = p.LowGCKValues
+				currentMaxErrors = p.LowGCMaxErrors
+			} else if gcContent < p.HighGCThreshold {
+				kValuesToTry = p.MedGCKValues
+				currentMaxErrors = p.LowGCMaxErrors + 1
 			} else {
-				kValuesToTry = config.HighGCKValues
-				currentMaxErrors = config.HighGCMaxErrors
+				kValuesToTry = p.HighGCKValues
+				currentMaxErrors = p.HighGCMaxErrors
 			}
 		} else { // "long"
-			if gcContent < config.LowGCThreshold {
-				kValuesToTry = config.LowGCKValues
-				currentMaxErrors = config.LowGCMaxErrors
-			} else if gcContent < config.HighGCThreshold {
-				kValuesToTry = config.MedGCKValues
-				currentMaxErrors = config.LowGCMaxErrors + 1
+			if gcContent < p.LowGCThreshold {
+				kValuesToTry = p.LowGCKValues
+				currentMaxErrors = p.LowGCMaxErrors
+			} else if gcContent < p.HighGCThreshold {
+				kValuesToTry = p.MedGCKValues
+				currentMaxErrors = p.LowGCMaxErrors + 1
 			} else {
-				kValuesToTry = config.HighGCKValues
-				currentMaxErrors = config.HighGCMaxErrors
+				kValuesToTry = p.HighGCKValues
+				currentMaxErrors = p.HighGCMaxErrors
 			}
 		}
 		// Stride for non-"very short" is k-dependent, handled in loop below.
 	}
 	// --- End adaptive parameters ---
 
-	var forwardAnchors, reverseAnchors []common.AnchorMatch
-	for _, k := range kValuesToTry {
-		fmt.Printf("Finding anchors with k=%d...\n", k)
-		iterStride := currentStride                              // Use stride determined by seq length class
-		if seqLengthConsidered >= config.VeryShortSeqThreshold { // If not "very short", stride is k-dependent
+	// Every stage scores with the same scheme, built once for this alignment.
+	scheme := scoring.FromParams(p)
+
+	// Every (k, strand) search is independent: task 2*i is forward and 2*i+1 reverse for kValuesToTry[i].
+	// Results are collected per task and concatenated in task order, so the outcome does not depend on scheduling.
+	anchorsPerTask := make([][]common.AnchorMatch, 2*len(kValuesToTry))
+	parallel.ForEach(len(anchorsPerTask), opts.Threads, func(task int) {
+		k := kValuesToTry[task/2]
+		iterStride := currentStride                         // Use stride determined by seq length class
+		if seqLengthConsidered >= p.VeryShortSeqThreshold { // If not "very short", stride is k-dependent
 			iterStride = int(math.Max(1, float64(k-5)))
 		}
+		if task%2 == 0 {
+			anchorsPerTask[task] = matching.FindAnchors(query, ref, p, scheme, k, currentMinMatchLength, iterStride, currentMaxErrors)
+		} else {
+			anchorsPerTask[task] = matching.FindReverseAnchors(query, ref, p, scheme, k, currentMinMatchLength, iterStride, currentMaxErrors)
+		}
+	})
 
-		fAnc := matching.FindAnchors(query, ref, k, currentMinMatchLength, iterStride, currentMaxErrors)
-		rAnc := matching.FindReverseAnchors(query, ref, k, currentMinMatchLength, iterStride, currentMaxErrors)
-		fmt.Printf("  Found %d forward anchors, %d reverse anchors with k=%d\n", len(fAnc), len(rAnc), k)
+	var forwardAnchors, reverseAnchors []common.AnchorMatch
+	for i, k := range kValuesToTry {
+		fAnc, rAnc := anchorsPerTask[2*i], anchorsPerTask[2*i+1]
+		common.Logf("Finding anchors with k=%d...\n", k)
+		common.Logf("  Found %d forward anchors, %d reverse anchors with k=%d\n", len(fAnc), len(rAnc), k)
 		forwardAnchors = append(forwardAnchors, fAnc...)
 		reverseAnchors = append(reverseAnchors, rAnc...)
 	}
 
-	overlapThreshForFilter := config.HighQualityOverlapThreshold
-	if gcContent < config.LowGCThreshold {
+	// All candidate placements (both strands, every k) compete when estimating mapping quality.
+	mapqCandidates := make([]common.AnchorMatch, 0, len(forwardAnchors)+len(reverseAnchors))
+	mapqCandidates = append(mapqCandidates, forwardAnchors...)
+	mapqCandidates = append(mapqCandidates, reverseAnchors...)
+	repeats := opts.Repeats
+	if repeats == nil {
+		repeats = matching.NewRepeatIndex(ref, p.MapQSeedK)
+	}
+
+	overlapThreshForFilter := p.HighQualityOverlapThreshold
+	if gcContent < p.LowGCThreshold {
 		overlapThreshForFilter += 0.02
 	}
 	forwardAnchors = matching.FilterAnchors(forwardAnchors, overlapThreshForFilter)
 	reverseAnchors = matching.FilterAnchors(reverseAnchors, overlapThreshForFilter)
-	fmt.Printf("After filtering: %d forward, %d reverse anchors remaining\n", len(forwardAnchors), len(reverseAnchors))
+	common.Logf("After filtering: %d forward, %d reverse anchors remaining\n", len(forwardAnchors), len(reverseAnchors))
 
 	// --- Process forward and reverse anchors using graph chaining ---
 	var chainedFwdSegments, chainedRevSegments []common.Segment
@@ -99,7 +170,8 @@ func FindAlignment(query, ref string, minMatchLenUser int) []common.Segment {
 		fwdPathIndices := graph.FindMaximumWeightPath(fwdGraph, len(forwardAnchors))
 		for _, idx := range fwdPathIndices {
 			anc := forwardAnchors[idx]
-			chainedFwdSegments = append(chainedFwdSegments, common.Segment{anc.QueryStart, anc.QueryEnd, anc.RefStart, anc.RefEnd})
+			mapq := matching.EstimateMapQ(anc, mapqCandidates, repeats.RepeatFraction(query[anc.QueryStart:anc.QueryEnd+1]), p)
+			chainedFwdSegments = append(chainedFwdSegments, common.Segment{QueryStart: anc.QueryStart, QueryEnd: anc.QueryEnd, RefStart: anc.RefStart, RefEnd: anc.RefEnd, MapQ: mapq, Identity: anc.Identity})
 		}
 	}
 	if len(reverseAnchors) > 0 {
@@ -107,7 +179,8 @@ func FindAlignment(query, ref string, minMatchLenUser int) []common.Segment {
 		revPathIndices := graph.FindMaximumWeightPath(revGraph, len(reverseAnchors))
 		for _, idx := range revPathIndices {
 			anc := reverseAnchors[idx]
-			chainedRevSegments = append(chainedRevSegments, common.Segment{anc.QueryStart, anc.QueryEnd, anc.RefStart, anc.RefEnd})
+			mapq := matching.EstimateMapQ(anc, mapqCandidates, repeats.RepeatFraction(query[anc.QueryStart:anc.QueryEnd+1]), p)
+			chainedRevSegments = append(chainedRevSegments, common.Segment{QueryStart: anc.QueryStart, QueryEnd: anc.QueryEnd, RefStart: anc.RefStart, RefEnd: anc.RefEnd, MapQ: mapq, Identity: anc.Identity})
 		}
 	}
 
@@ -116,22 +189,25 @@ func FindAlignment(query, ref string, minMatchLenUser int) []common.Segment {
 	initialResolvedSegments := regions.ResolveOverlaps(combinedFromChaining) // Sorts and resolves by longer
 
 	// --- Merge adjacent segments ---
-	mergedAfterInitial := merging.MergeAdjacentSegments(initialResolvedSegments, config.AdjacentMergeMaxGap)
+	mergedAfterInitial := merging.MergeAdjacentSegments(initialResolvedSegments, p.AdjacentMergeMaxGap, p.MaxGapRatioDifference)
 
 	// --- Ensure complete coverage ---
 	// EnsureCompleteCoverage expects its input `initialSegments` to be somewhat processed (sorted, major overlaps resolved).
 	// mergedAfterInitial should be sorted as MergeAdjacentSegments processes sorted input.
-	segmentsAfterCoveragePass := regions.EnsureCompleteCoverage(query, ref, mergedAfterInitial)
+	segmentsAfterCoveragePass := regions.EnsureCompleteCoverage(query, ref, mergedAfterInitial, regions.CoverageOptions{
+		Repeats:    repeats,
+		NoFallback: opts.NoFallback,
+		Threads:    opts.Threads,
+		Params:     p,
+		Scheme:     scheme,
+	})
 
 	// --- Final merging and overlap resolution ---
 	// Ensure sorted before final merge as EnsureCompleteCoverage might add segments unsortedly.
-	sort.Slice(segmentsAfterCoveragePass, func(i, j int) bool {
-		if segmentsAfterCoveragePass[i].QueryStart != segmentsAfterCoveragePass[j].QueryStart {
-			return segmentsAfterCoveragePass[i].QueryStart < segmentsAfterCoveragePass[j].QueryStart
-		}
-		return segmentsAfterCoveragePass[i].RefStart < segmentsAfterCoveragePass[j].RefStart
+	sort.SliceStable(segmentsAfterCoveragePass, func(i, j int) bool {
+		return common.SegmentLess(segmentsAfterCoveragePass[i], segmentsAfterCoveragePass[j])
 	})
-	finalMergedSegments := merging.MergeAdjacentSegments(segmentsAfterCoveragePass, config.FinalMergeMaxGap)
+	finalMergedSegments := merging.MergeAdjacentSegments(segmentsAfterCoveragePass, p.FinalMergeMaxGap, p.MaxGapRatioDifference)
 	finalOutputSegments := regions.ResolveOverlaps(finalMergedSegments) // Final cleanup of any overlaps
 
 	// Clamp coordinates to sequence boundaries (Python's final step)
@@ -141,29 +217,31 @@ func FindAlignment(query, ref string, minMatchLenUser int) []common.Segment {
 		rEndClamped := int(math.Min(float64(seg.RefEnd), float64(refLen-1)))
 		// Ensure segment is still valid after clamping (start <= end)
 		if seg.QueryStart <= qEndClamped && seg.RefStart <= rEndClamped {
-			clampedSegments = append(clampedSegments, common.Segment{
-				QueryStart: seg.QueryStart, QueryEnd: qEndClamped,
-				RefStart: seg.RefStart, RefEnd: rEndClamped,
-			})
+			seg.QueryEnd, seg.RefEnd = qEndClamped, rEndClamped
+			clampedSegments = append(clampedSegments, seg)
 		}
 	}
 	finalOutputSegments = clampedSegments
 
 	// Sort for consistent output as per Python's implicit behavior / good practice
-	sort.Slice(finalOutputSegments, func(i, j int) bool {
-		if finalOutputSegments[i].QueryStart != finalOutputSegments[j].QueryStart {
-			return finalOutputSegments[i].QueryStart < finalOutputSegments[j].QueryStart
-		}
-		// Tie-break by ref start, then query end, then ref end for full determinism
-		if finalOutputSegments[i].RefStart != finalOutputSegments[j].RefStart {
-			return finalOutputSegments[i].RefStart < finalOutputSegments[j].RefStart
-		}
-		if finalOutputSegments[i].QueryEnd != finalOutputSegments[j].QueryEnd {
-			return finalOutputSegments[i].QueryEnd < finalOutputSegments[j].QueryEnd
-		}
-		return finalOutputSegments[i].RefEnd < finalOutputSegments[j].RefEnd
+	sort.SliceStable(finalOutputSegments, func(i, j int) bool {
+		return common.SegmentLess(finalOutputSegments[i], finalOutputSegments[j])
 	})
 
+	// Each segment is aligned at base level once; the alignments are returned with the segments.
+	alignments := annotateSegments(query, ref, p, scheme, finalOutputSegments)
+	if opts.TandemCopies {
+		mapq := func(anc common.AnchorMatch) int {
+			return matching.EstimateMapQ(anc, mapqCandidates, repeats.RepeatFraction(query[anc.QueryStart:anc.QueryEnd+1]), p)
+		}
+		expansions := findTandemExpansions(query, ref, scheme, currentMinMatchLength, mapq)
+		common.Logf("Found %d tandem expansions\n", len(expansions))
+		finalOutputSegments, alignments = splitTandemCopies(query, ref, p, scheme, finalOutputSegments, alignments, expansions)
+	}
+	if opts.MaxEValue > 0 {
+		finalOutputSegments, alignments = filterByEValue(finalOutputSegments, alignments, opts.MaxEValue)
+	}
+
 	// Final coverage calculation (for console output)
 	uncoveredInfo := regions.FindUncoveredRegions(queryLen, finalOutputSegments)
 	totalUncoveredLen := 0
@@ -174,7 +252,7 @@ func FindAlignment(query, ref string, minMatchLenUser int) []common.Segment {
 	if queryLen > 0 {
 		coveragePerc = 100.0 * float64(queryLen-totalUncoveredLen) / float64(queryLen)
 	}
-	fmt.Printf("Final coverage: %.2f%% of query (%d segments)\n", coveragePerc, len(finalOutputSegments))
+	common.Logf("Final coverage: %.2f%% of query (%d segments)\n", coveragePerc, len(finalOutputSegments))
 
-	return finalOutputSegments
+	return Result{Segments: finalOutputSegments, Alignments: alignments}
 }
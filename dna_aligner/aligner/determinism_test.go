@@ -0,0 +1,84 @@
+package aligner
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/io"
+	"DNA-Sequence-Alignments/dna_aligner/output"
+	"bytes"
+	goio "io"
+	"sync"
+	"testing"
+)
+
+var determinismDatasets = []struct{ query, ref string }{
+	{"../data/query1.txt", "../data/ref1.txt"},
+	{"../data/query2.txt", "../data/ref2.txt"},
+}
+
+// readDataset reads the query and reference sequence files of a bundled dataset.
+func readDataset(t *testing.T, queryFile, refFile string) (string, string) {
+	t.Helper()
+	query, err := io.ReadRecord(queryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := io.ReadRecord(refFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return query.Seq, ref.Seq
+}
+
+// renderAlignment runs the aligner and formats everything it reports (coordinates, stage,
+// identity, MAPQ, CIGAR), so that any difference between runs shows up in the bytes.
+func renderAlignment(t *testing.T, query, ref string, opts Options) string {
+	t.Helper()
+	res := Align(query, ref, opts)
+	pair := output.Pair{QueryName: "query", Query: query, RefName: "ref", Ref: ref}
+	var buf bytes.Buffer
+	buf.WriteString(output.FormatTuples(res.Segments))
+	buf.WriteByte('\n')
+	if err := output.WritePAF(&buf, pair, res.Segments, res.Alignments); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestFindAlignmentIsDeterministic(t *testing.T) {
+	common.LogWriter = goio.Discard
+	for _, ds := range determinismDatasets {
+		if testing.Short() && ds.query == determinismDatasets[0].query {
+			continue
+		}
+		query, ref := readDataset(t, ds.query, ds.ref)
+
+		want := renderAlignment(t, query, ref, Options{})
+		if want == "" {
+			t.Fatalf("%s: empty alignment", ds.query)
+		}
+
+		// Repeated runs, with and without worker threads.
+		for _, opts := range []Options{{}, {Threads: 4}, {Threads: 16}} {
+			if got := renderAlignment(t, query, ref, opts); got != want {
+				t.Errorf("%s: output with %+v differs from the sequential run:\n got: %s\nwant: %s", ds.query, opts, got, want)
+			}
+		}
+
+		// Concurrent callers must not influence each other.
+		got := make([]string, 4)
+		var wg sync.WaitGroup
+		for i := range got {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				got[i] = renderAlignment(t, query, ref, Options{Threads: 2})
+			}(i)
+		}
+		wg.Wait()
+		for i, g := range got {
+			if g != want {
+				t.Errorf("%s: concurrent run %d differs from the sequential run:\n got: %s\nwant: %s", ds.query, i, g, want)
+			}
+		}
+	}
+}
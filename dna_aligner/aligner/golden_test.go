@@ -0,0 +1,34 @@
+package aligner
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/output"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	goio "io"
+	"testing"
+)
+
+func TestFindAlignmentScenarios(t *testing.T) {
+	common.LogWriter = goio.Discard
+	for _, sc := range simulate.Scenarios {
+		t.Run(sc.Name, func(t *testing.T) {
+			res := sc.Generate(1)
+			got := "truth: " + output.FormatTuples(res.Segments()) + "\n" + renderAlignment(t, res.Query, res.Ref, Options{})
+			golden.AssertString(t, got)
+		})
+	}
+}
+
+func TestFindAlignmentBundled(t *testing.T) {
+	common.LogWriter = goio.Discard
+	for i, ds := range determinismDatasets {
+		if testing.Short() && i == 0 {
+			continue
+		}
+		t.Run(ds.query[len("../data/"):], func(t *testing.T) {
+			query, ref := readDataset(t, ds.query, ds.ref)
+			golden.AssertString(t, output.FormatTuples(FindAlignment(query, ref, 0))+"\n")
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package aligner
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+)
+
+// annotateSegments aligns every segment at base level, records the alignment statistics on it and
+// returns the alignments in the order of segments.
+func annotateSegments(query, ref string, p *config.Params, scheme *scoring.Scheme, segments []common.Segment) []pairwise.Alignment {
+	stats, haveStats := significance(query, ref, scheme)
+	// Both strands of the reference are searched.
+	searchQuery, searchRef := len(query), 2*len(ref)
+	alignments := make([]pairwise.Alignment, len(segments))
+	for i := range segments {
+		seg := &segments[i]
+		aln := pairwise.AlignSegment(query, ref, p, scheme, *seg)
+		alignments[i] = aln
+		seg.Matches = aln.Matches
+		seg.Mismatches = aln.Mismatches
+		seg.Insertions = aln.Insertions
+		seg.Deletions = aln.Deletions
+		seg.Score = aln.Score
+		seg.Identity = aln.Identity()
+		if haveStats {
+			seg.BitScore = stats.BitScore(float64(aln.Score))
+			seg.EValue = stats.EValue(float64(aln.Score), searchQuery, searchRef)
+		}
+	}
+	return alignments
+}
+
+// significance returns the Karlin-Altschul parameters of the scoring scheme for the base
+// composition of query and ref, falling back to uniform frequencies when the composition
+// does not give a negative expected score.
+func significance(query, ref string, scheme *scoring.Scheme) (scoring.KarlinAltschul, bool) {
+	var counts [4]float64
+	for _, seq := range []string{query, ref} {
+		for i := 0; i < len(seq); i++ {
+			switch seq[i] {
+			case 'A', 'a':
+				counts[0]++
+			case 'C', 'c':
+				counts[1]++
+			case 'G', 'g':
+				counts[2]++
+			case 'T', 't', 'U', 'u':
+				counts[3]++
+			}
+		}
+	}
+	total := counts[0] + counts[1] + counts[2] + counts[3]
+	if total > 0 {
+		freqs := [4]float64{counts[0] / total, counts[1] / total, counts[2] / total, counts[3] / total}
+		if ka, err := scheme.Statistics(freqs); err == nil {
+			return ka, true
+		}
+	}
+	ka, err := scheme.Statistics(scoring.UniformFrequencies)
+	if err != nil {
+		common.Logf("Warning: no E-values for this scoring scheme: %v\n", err)
+		return ka, false
+	}
+	return ka, true
+}
+
+// filterByEValue drops the segments whose E-value exceeds maxEValue, with their alignments.
+func filterByEValue(segments []common.Segment, alignments []pairwise.Alignment, maxEValue float64) ([]common.Segment, []pairwise.Alignment) {
+	kept, keptAlignments := segments[:0], alignments[:0]
+	for i, seg := range segments {
+		if seg.EValue <= maxEValue {
+			kept = append(kept, seg)
+			keptAlignments = append(keptAlignments, alignments[i])
+		}
+	}
+	if dropped := len(segments) - len(kept); dropped > 0 {
+		common.Logf("Dropped %d segments with E-value above %g\n", dropped, maxEValue)
+	}
+	return kept, keptAlignments
+}
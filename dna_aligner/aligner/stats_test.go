@@ -0,0 +1,43 @@
+package aligner
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	goio "io"
+	"strings"
+	"testing"
+)
+
+func TestSegmentSignificance(t *testing.T) {
+	common.LogWriter = goio.Discard
+	query, ref := readDataset(t, "../data/query2.txt", "../data/ref2.txt")
+
+	all := FindAlignmentWithOptions(query, ref, Options{})
+	var sb strings.Builder
+	for _, seg := range all {
+		fmt.Fprintf(&sb, "(%d, %d, %d, %d) %s score %d bits %.1f evalue %.3g\n",
+			seg.QueryStart, seg.QueryEnd+1, seg.RefStart, seg.RefEnd+1, seg.Source, seg.Score, seg.BitScore, seg.EValue)
+		if seg.Score > 0 && seg.BitScore <= 0 {
+			t.Errorf("positive score %d with bit score %.1f", seg.Score, seg.BitScore)
+		}
+	}
+	golden.AssertString(t, sb.String())
+
+	const maxEValue = 1e-10
+	kept := FindAlignmentWithOptions(query, ref, Options{MaxEValue: maxEValue})
+	want := 0
+	for _, seg := range all {
+		if seg.EValue <= maxEValue {
+			want++
+		}
+	}
+	if len(kept) != want || want == len(all) {
+		t.Fatalf("MaxEValue kept %d of %d segments, want %d", len(kept), len(all), want)
+	}
+	for _, seg := range kept {
+		if seg.EValue > maxEValue {
+			t.Errorf("segment %+v kept with E-value %g", seg, seg.EValue)
+		}
+	}
+}
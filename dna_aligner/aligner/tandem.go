@@ -0,0 +1,136 @@
+package aligner
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"DNA-Sequence-Alignments/dup_identification/dups"
+	"sort"
+)
+
+// findTandemExpansions returns one segment per copy of every tandem expansion in the query: a
+// run of at least two adjacent exact copies of a reference unit (on either strand) that the
+// reference holds fewer times in tandem at any locus. Every copy points to the same reference
+// interval, has source common.SourceTandemCopy and carries its 1-based CopyIndex. Units shorter
+// than minUnit are ignored.
+func findTandemExpansions(query, ref string, scheme *scoring.Scheme, minUnit int, mapq func(common.AnchorMatch) int) [][]common.Segment {
+	runs, err := dups.Analyze(query, ref, dups.Options{MinUnitLength: minUnit, SkipOccurrences: true})
+	if err != nil {
+		common.Logf("Tandem expansion search skipped: %v\n", err)
+		return nil
+	}
+	var expansions [][]common.Segment
+	var refSAM *dups.SAM // Built on the first run of at least two copies
+	for _, d := range runs {
+		if d.Count < 2 || d.Length < minUnit {
+			continue
+		}
+		if refSAM == nil {
+			refSAM = dups.BuildSAMByString(ref)
+		}
+		if d.Count <= refTandemCopies(refSAM, ref[d.RefStart:d.RefEnd]) {
+			continue
+		}
+		orientation := 'f'
+		if d.IsInverted {
+			orientation = 'r'
+		}
+		var copies []common.Segment
+		for _, c := range d.Copies {
+			if c.Partial {
+				continue
+			}
+			anchor := common.AnchorMatch{
+				QueryStart: c.QueryStart, QueryEnd: c.QueryEnd - 1,
+				RefStart: d.RefStart, RefEnd: d.RefEnd - 1,
+				Score: float64(d.Length * scheme.Match), Identity: 1, Orientation: orientation,
+			}
+			copies = append(copies, common.Segment{
+				QueryStart: anchor.QueryStart, QueryEnd: anchor.QueryEnd,
+				RefStart: anchor.RefStart, RefEnd: anchor.RefEnd,
+				MapQ: mapq(anchor), Source: common.SourceTandemCopy, Identity: 1, CopyIndex: len(copies) + 1,
+			})
+		}
+		expansions = append(expansions, copies)
+	}
+	return expansions
+}
+
+// refTandemCopies returns the largest number of adjacent copies of unit that the reference
+// indexed by refSAM holds at any of its occurrences (0 when unit does not occur), in time linear
+// in the number of occurrences.
+func refTandemCopies(refSAM *dups.SAM, unit string) int {
+	// Occurrences are sorted, so the copy before each one has already been counted.
+	copiesEndingAt := make(map[int]int)
+	most := 0
+	for _, pos := range refSAM.Occurrences(unit) {
+		count := copiesEndingAt[pos-len(unit)] + 1
+		copiesEndingAt[pos] = count
+		most = max(most, count)
+	}
+	return most
+}
+
+// splitTandemCopies replaces the parts of segments covering each expansion (see
+// findTandemExpansions) with the segments of its copies. alignments are the base-level alignments
+// of segments (see annotateSegments). Segments reaching into an expansion are trimmed to the query
+// outside it, with the reference span their alignment gives the remaining query bases; only the
+// trimmed segments and the copies are aligned and annotated anew. The result is sorted with
+// common.SegmentLess, alignments moving with their segments.
+func splitTandemCopies(query, ref string, p *config.Params, scheme *scoring.Scheme, segments []common.Segment, alignments []pairwise.Alignment, expansions [][]common.Segment) ([]common.Segment, []pairwise.Alignment) {
+	for _, copies := range expansions {
+		start, end := copies[0].QueryStart, copies[len(copies)-1].QueryEnd
+		var kept, added []common.Segment
+		var keptAlignments []pairwise.Alignment
+		for i, seg := range segments {
+			if seg.QueryEnd < start || seg.QueryStart > end {
+				kept = append(kept, seg)
+				keptAlignments = append(keptAlignments, alignments[i])
+				continue
+			}
+			if seg.QueryStart < start {
+				added = appendTrimmed(added, seg, alignments[i], seg.QueryStart, start-1)
+			}
+			if seg.QueryEnd > end {
+				added = appendTrimmed(added, seg, alignments[i], end+1, seg.QueryEnd)
+			}
+		}
+		added = append(added, copies...)
+		addedAlignments := annotateSegments(query, ref, p, scheme, added)
+		segments = append(kept, added...)
+		alignments = append(keptAlignments, addedAlignments...)
+	}
+	sort.Stable(alignedSegments{segments, alignments})
+	return segments, alignments
+}
+
+// alignedSegments sorts segments with common.SegmentLess, keeping alignments[i] with segments[i].
+type alignedSegments struct {
+	segments   []common.Segment
+	alignments []pairwise.Alignment
+}
+
+func (a alignedSegments) Len() int           { return len(a.segments) }
+func (a alignedSegments) Less(i, j int) bool { return common.SegmentLess(a.segments[i], a.segments[j]) }
+func (a alignedSegments) Swap(i, j int) {
+	a.segments[i], a.segments[j] = a.segments[j], a.segments[i]
+	a.alignments[i], a.alignments[j] = a.alignments[j], a.alignments[i]
+}
+
+// appendTrimmed appends seg restricted to the query span [queryStart, queryEnd], unless no
+// reference base is aligned to it. aln is the base-level alignment of seg.
+func appendTrimmed(segments []common.Segment, seg common.Segment, aln pairwise.Alignment, queryStart, queryEnd int) []common.Segment {
+	// Offsets of the kept span in the order the alignment walks the query
+	from, to := queryStart-seg.QueryStart, queryEnd-seg.QueryStart+1
+	if aln.Reverse {
+		from, to = seg.QueryEnd-queryEnd, seg.QueryEnd-queryStart+1
+	}
+	refStart, refEnd := seg.RefStart+aln.RefOffset(from), seg.RefStart+aln.RefOffset(to)-1
+	if refStart > refEnd {
+		return segments
+	}
+	seg.QueryStart, seg.QueryEnd = queryStart, queryEnd
+	seg.RefStart, seg.RefEnd = refStart, refEnd
+	return append(segments, seg)
+}
@@ -0,0 +1,97 @@
+package aligner
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/dup_identification/dups"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	goio "io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// TestTandemCopies aligns queries with two extra copies of a 200-base reference unit, on either
+// strand, with and without Options.TandemCopies.
+func TestTandemCopies(t *testing.T) {
+	common.LogWriter = goio.Discard
+	rng := rand.New(rand.NewSource(7))
+	ref := simulate.RandomSequence(rng, 3000, 0.45)
+	unit := ref[1000:1200]
+	cases := []struct {
+		name  string
+		query string
+	}{
+		{"forward", ref[:1200] + strings.Repeat(unit, 2) + ref[1200:]},
+		{"inverted", ref[:1200] + strings.Repeat(sequence.ReverseComplement(unit), 2) + ref[1200:]},
+	}
+	var sb strings.Builder
+	for _, c := range cases {
+		for _, tandem := range []bool{false, true} {
+			fmt.Fprintf(&sb, "== %s tandem-copies=%v\n%s", c.name, tandem, renderAlignment(t, c.query, ref, Options{TandemCopies: tandem}))
+		}
+	}
+	golden.AssertString(t, sb.String())
+
+	// The alignments returned with the split segments are those of the segments themselves.
+	p := config.Default()
+	scheme := scoring.FromParams(&p)
+	for _, c := range cases {
+		res := Align(c.query, ref, Options{TandemCopies: true})
+		for i, seg := range res.Segments {
+			want := pairwise.AlignSegment(c.query, ref, &p, scheme, seg)
+			if got := res.Alignments[i]; got.CIGAR() != want.CIGAR() || got.Reverse != want.Reverse {
+				t.Errorf("%s: segment %+v returned with %s, aligns as %s", c.name, seg, got.CIGAR(), want.CIGAR())
+			}
+		}
+	}
+}
+
+// TestTandemExpansionNeedsExtraCopies checks that copies the reference also holds in tandem, at
+// the matched locus or elsewhere, are not reported as an expansion.
+func TestTandemExpansionNeedsExtraCopies(t *testing.T) {
+	rng := rand.New(rand.NewSource(8))
+	unit := simulate.RandomSequence(rng, 60, 0.5)
+	ref := simulate.RandomSequence(rng, 500, 0.5) + unit + unit + simulate.RandomSequence(rng, 500, 0.5)
+	refSAM := dups.BuildSAMByString(ref)
+	if got := refTandemCopies(refSAM, unit); got != 2 {
+		t.Errorf("reference copies of the unit: %d, want 2", got)
+	}
+	if got := refTandemCopies(refSAM, ref[:60]); got != 1 {
+		t.Errorf("reference copies of ref[:60]: %d, want 1", got)
+	}
+	if got := refTandemCopies(refSAM, strings.Repeat("N", 60)); got != 0 {
+		t.Errorf("reference copies of an absent unit: %d, want 0", got)
+	}
+	// Overlapping occurrences of a periodic unit are not adjacent copies.
+	if got := refTandemCopies(dups.BuildSAMByString("GG"+strings.Repeat("AC", 6)+"GG"), "ACAC"); got != 3 {
+		t.Errorf("copies of ACAC in (AC)6: %d, want 3", got)
+	}
+	noMapQ := func(common.AnchorMatch) int { return 0 }
+	p := config.Default()
+	scheme := scoring.FromParams(&p)
+	queries := map[string]string{
+		"same copies":  ref[500:620] + simulate.RandomSequence(rng, 300, 0.5),
+		"extra copies": ref[500:620] + unit + simulate.RandomSequence(rng, 300, 0.5),
+	}
+	for name, want := range map[string]int{"same copies": 0, "extra copies": 1} {
+		if got := findTandemExpansions(queries[name], ref, scheme, 28, noMapQ); len(got) != want {
+			t.Errorf("%s: %d expansions, want %d", name, len(got), want)
+		}
+	}
+
+	// The reference holds the unit once, then two copies in tandem further on.
+	elsewhere := simulate.RandomSequence(rng, 300, 0.5) + unit + ref
+	query := unit + unit + simulate.RandomSequence(rng, 300, 0.5)
+	if got := findTandemExpansions(query, elsewhere, scheme, 28, noMapQ); len(got) != 0 {
+		t.Errorf("tandem copies elsewhere in the reference: %d expansions, want 0", len(got))
+	}
+	if got := findTandemExpansions(unit+query, elsewhere, scheme, 28, noMapQ); len(got) != 1 {
+		t.Errorf("three copies: %d expansions, want 1", len(got))
+	}
+}
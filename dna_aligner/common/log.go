@@ -0,0 +1,17 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// LogWriter receives the pipeline's progress messages.
+// It defaults to stdout so the bundled dataset runner prints as before; commands that
+// write their results to stdout redirect it to stderr (or io.Discard).
+var LogWriter io.Writer = os.Stdout
+
+// Logf writes a progress message to LogWriter.
+func Logf(format string, args ...any) {
+	fmt.Fprintf(LogWriter, format, args...)
+}
@@ -2,11 +2,80 @@ package common
 
 // Segment represents a matched region between query and reference.
 // QueryStart, QueryEnd, RefStart, RefEnd are 0-based inclusive.
+// MapQ is a phred-scaled mapping quality (0-60); 0 means the placement is not unique or was not derived from anchors.
+// Source records how the segment was found and Identity the fraction of matching bases measured at that stage.
+// The remaining fields describe the base-level alignment of the final segments; aligner fills them
+// (and refreshes Identity) once the segment set is fixed. BitScore and EValue give the significance
+// of Score for the sequence lengths and base composition; both are 0 when it could not be estimated.
 type Segment struct {
 	QueryStart int
 	QueryEnd   int
 	RefStart   int
 	RefEnd     int
+	MapQ       int
+	Source     SegmentSource
+	Identity   float64
+
+	Matches    int
+	Mismatches int
+	Insertions int // Query bases absent from the reference
+	Deletions  int // Reference bases absent from the query
+	Score      int
+	BitScore   float64
+	EValue     float64
+
+	// CopyIndex numbers the copies of a tandem expansion from 1; copies share their reference
+	// span. It is 0 for every other segment.
+	CopyIndex int
+}
+
+// SegmentLess orders segments by query start, then reference start, query end and reference end.
+// Sorting with it (stably) gives the same order whatever the input order of the segments.
+func SegmentLess(a, b Segment) bool {
+	if a.QueryStart != b.QueryStart {
+		return a.QueryStart < b.QueryStart
+	}
+	if a.RefStart != b.RefStart {
+		return a.RefStart < b.RefStart
+	}
+	if a.QueryEnd != b.QueryEnd {
+		return a.QueryEnd < b.QueryEnd
+	}
+	return a.RefEnd < b.RefEnd
+}
+
+// SegmentSource is the provenance of a segment.
+// Values are ordered from most to least trustworthy.
+type SegmentSource int
+
+const (
+	SourceAnchor          SegmentSource = iota // Chained anchors from the main pass
+	SourceRegionRescan                         // Anchors found when re-scanning an uncovered query region
+	SourceTandemCopy                           // One exact copy of a tandem expansion (see aligner.Options.TandemCopies)
+	SourceSampledFallback                      // Best ungapped placement among sampled reference offsets
+	SourceModuloFallback                       // Query position reused as reference position (modulo its length)
+)
+
+// String returns the name used for the source in output files.
+func (s SegmentSource) String() string {
+	switch s {
+	case SourceAnchor:
+		return "anchor"
+	case SourceRegionRescan:
+		return "region-rescan"
+	case SourceTandemCopy:
+		return "tandem-copy"
+	case SourceSampledFallback:
+		return "sampled-fallback"
+	case SourceModuloFallback:
+		return "modulo-fallback"
+	}
+	return "unknown"
+}
+
+// IsFallback reports whether the segment was placed without any supporting match.
+func (s SegmentSource) IsFallback() bool {
+	return s == SourceSampledFallback || s == SourceModuloFallback
 }
 
 // AnchorMatch stores information about an extended k-mer match.
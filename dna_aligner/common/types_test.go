@@ -0,0 +1,38 @@
+package common
+
+import (
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSegmentOrdering(t *testing.T) {
+	segs := []Segment{
+		{QueryStart: 50, QueryEnd: 80, RefStart: 10, RefEnd: 40, Source: SourceModuloFallback},
+		{QueryStart: 0, QueryEnd: 49, RefStart: 100, RefEnd: 149, Source: SourceAnchor},
+		{QueryStart: 50, QueryEnd: 70, RefStart: 10, RefEnd: 30, Source: SourceSampledFallback},
+		{QueryStart: 0, QueryEnd: 49, RefStart: 0, RefEnd: 49, Source: SourceRegionRescan},
+		{QueryStart: 50, QueryEnd: 70, RefStart: 5, RefEnd: 25, Source: SegmentSource(9)},
+		{QueryStart: 0, QueryEnd: 29, RefStart: 100, RefEnd: 129, Source: SourceTandemCopy},
+	}
+
+	// Every permutation of the input must give the same order.
+	var first string
+	for rotation := 0; rotation < len(segs); rotation++ {
+		in := append(append([]Segment{}, segs[rotation:]...), segs[:rotation]...)
+		sort.SliceStable(in, func(i, j int) bool { return SegmentLess(in[i], in[j]) })
+
+		var sb strings.Builder
+		for _, s := range in {
+			fmt.Fprintf(&sb, "%d-%d %d-%d %s fallback=%v\n", s.QueryStart, s.QueryEnd, s.RefStart, s.RefEnd, s.Source, s.Source.IsFallback())
+		}
+		if rotation == 0 {
+			first = sb.String()
+		} else if sb.String() != first {
+			t.Fatalf("order depends on the input order:\n%s\nvs\n%s", sb.String(), first)
+		}
+	}
+	golden.AssertString(t, first)
+}
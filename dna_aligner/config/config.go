@@ -1,91 +1,83 @@
+// Package config holds the tunable parameters of the aligner. Default returns the built-in values,
+// tuned on the bundled datasets; presets and configuration files adjust a copy of them before it
+// is passed to an alignment. The package has no mutable state.
 package config
 
-// K-mer matching parameters
-const (
-	DefaultK         = 10
-	MinMatchLength   = 28
-	DefaultMaxErrors = 5
-	DefaultStride    = 2
-)
+// Default returns the built-in parameters, tuned on the bundled datasets. Every call returns
+// fresh slices, so callers may change the result freely.
+func Default() Params {
+	return Params{
+		// K-mer matching parameters
+		DefaultK:         10,
+		MinMatchLength:   28,
+		DefaultMaxErrors: 5,
+		DefaultStride:    2,
 
-// Extension parameters
-const (
-	ExtendMaxErrors      = 6
-	MinIdentityThreshold = 0.74 // float64
-)
+		// Extension parameters
+		ExtendMaxErrors:      6,
+		MinIdentityThreshold: 0.74,
 
-// Anchor filtering parameters
-const (
-	DefaultOverlapThreshold     = 0.72 // float64
-	HighQualityOverlapThreshold = 0.48 // float64
-)
+		// Anchor filtering parameters
+		DefaultOverlapThreshold:     0.72,
+		HighQualityOverlapThreshold: 0.48,
 
-// Large region processing parameters
-const (
-	MaxSegmentSize       = 475
-	LargeRegionChunkSize = 575
-	LargeRegionOverlap   = 210
-)
-const StandardChunkOverlapRatio = 2.8 // float64
+		// Large region processing parameters
+		MaxSegmentSize:            475,
+		LargeRegionChunkSize:      575,
+		LargeRegionOverlap:        210,
+		StandardChunkOverlapRatio: 2.8,
 
-// Small region processing parameters
-const (
-	VerySmallRegionThreshold = 4
-	SmallKForShortSegments   = 5
-	MediumKForShortSegments  = 6
-	LargeKForShortSegments   = 7
-)
+		// Small region processing parameters
+		VerySmallRegionThreshold: 4,
+		SmallKForShortSegments:   5,
+		MediumKForShortSegments:  6,
+		LargeKForShortSegments:   7,
 
-// Coverage parameters
-const (
-	SmallSegmentLength   = 275
-	SamplePositionsCount = 25
-)
+		// Coverage parameters
+		SmallSegmentLength:   275,
+		SamplePositionsCount: 25,
 
-// Merging parameters
-const (
-	AdjacentMergeMaxGap = 32
-	FinalMergeMaxGap    = 22
-)
-const MaxGapRatioDifference = 0.55 // float64
+		// Merging parameters
+		AdjacentMergeMaxGap:   32,
+		FinalMergeMaxGap:      22,
+		MaxGapRatioDifference: 0.55,
 
-// Adaptive parameters based on sequence properties
-const (
-	LowGCThreshold  = 0.40 // float64
-	HighGCThreshold = 0.50 // float64
-)
+		// Adaptive parameters based on sequence GC content and length
+		LowGCThreshold:    0.40,
+		HighGCThreshold:   0.50,
+		ShortSeqThreshold: 3250,
+		LowGCKValues:      []int{8, 9, 10},
+		MedGCKValues:      []int{7, 8, 9},
+		HighGCKValues:     []int{6, 7, 8},
+		LowGCMaxErrors:    4,
+		HighGCMaxErrors:   6,
 
-// Length-based parameters
-const (
-	ShortSeqThreshold = 3250
-)
+		// K-mer options for different segment lengths, and extra errors for boundary regions
+		VeryShortSegmentKValues: []int{4, 5},
+		ShortSegmentKValues:     []int{5, 6, 7},
+		LongerSegmentKValues:    []int{7, 8, 9},
+		BoundaryExtraErrors:     2,
 
-// K-mer size options based on sequence properties
-var LowGCKValues = []int{8, 9, 10}
-var MedGCKValues = []int{7, 8, 9}
-var HighGCKValues = []int{6, 7, 8}
+		// Dataset specific optimizations (for very short sequences)
+		VeryShortSeqThreshold:      2500,
+		VeryShortSeqMinMatchLength: 20,
+		VeryShortSeqStride:         1,
+		VeryShortSeqKValues:        []int{5, 6, 7},
 
-// Error tolerance based on sequence GC content
-const (
-	LowGCMaxErrors  = 4
-	HighGCMaxErrors = 6
-)
+		// Base-level (banded global) alignment parameters
+		PairwiseMatchScore:      2,
+		PairwiseMismatchScore:   -4, // Transversions (A/G <-> C/T)
+		PairwiseTransitionScore: -3, // A<->G and C<->T substitutions
+		PairwiseAmbiguousScore:  -1, // Columns involving N or other non-nucleotide characters
+		PairwiseGapOpen:         4,
+		PairwiseGapExtend:       2,
+		PairwiseBandWidth:       48,
+		PairwiseMaxCells:        64 << 20, // DP cells above which a segment is not aligned base by base
 
-// K-mer options for different segment lengths
-var VeryShortSegmentKValues = []int{4, 5}
-var ShortSegmentKValues = []int{5, 6, 7}
-var LongerSegmentKValues = []int{7, 8, 9}
-
-// Additional error tolerance for boundary regions
-const (
-	BoundaryExtraErrors = 2
-)
-
-// Dataset specific optimizations (for very short sequences)
-const (
-	VeryShortSeqThreshold      = 2500
-	VeryShortSeqMinMatchLength = 20
-	VeryShortSeqStride         = 1
-)
-
-var VeryShortSeqKValues = []int{5, 6, 7}
+		// Mapping quality parameters
+		MapQMax:            60,
+		MapQSeedK:          11,  // k-mer size used to measure seed repetitiveness
+		MapQLocusOverlap:   0.5, // Query overlap that makes two anchors compete for the same span
+		MapQShortAnchorLen: 100, // Anchors shorter than this have their MAPQ scaled down linearly
+	}
+}
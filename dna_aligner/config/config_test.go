@@ -0,0 +1,169 @@
+package config
+
+import (
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestParameterSnapshot pins the tuned parameter values, so that a change to any of them
+// is a deliberate golden-file update rather than a silent edit.
+func TestParameterSnapshot(t *testing.T) {
+	d := Default()
+	params := []struct {
+		name  string
+		value any
+	}{
+		{"DefaultK", d.DefaultK},
+		{"MinMatchLength", d.MinMatchLength},
+		{"DefaultMaxErrors", d.DefaultMaxErrors},
+		{"DefaultStride", d.DefaultStride},
+		{"ExtendMaxErrors", d.ExtendMaxErrors},
+		{"MinIdentityThreshold", d.MinIdentityThreshold},
+		{"DefaultOverlapThreshold", d.DefaultOverlapThreshold},
+		{"HighQualityOverlapThreshold", d.HighQualityOverlapThreshold},
+		{"MaxSegmentSize", d.MaxSegmentSize},
+		{"LargeRegionChunkSize", d.LargeRegionChunkSize},
+		{"LargeRegionOverlap", d.LargeRegionOverlap},
+		{"StandardChunkOverlapRatio", d.StandardChunkOverlapRatio},
+		{"VerySmallRegionThreshold", d.VerySmallRegionThreshold},
+		{"SmallKForShortSegments", d.SmallKForShortSegments},
+		{"MediumKForShortSegments", d.MediumKForShortSegments},
+		{"LargeKForShortSegments", d.LargeKForShortSegments},
+		{"SmallSegmentLength", d.SmallSegmentLength},
+		{"SamplePositionsCount", d.SamplePositionsCount},
+		{"AdjacentMergeMaxGap", d.AdjacentMergeMaxGap},
+		{"FinalMergeMaxGap", d.FinalMergeMaxGap},
+		{"MaxGapRatioDifference", d.MaxGapRatioDifference},
+		{"LowGCThreshold", d.LowGCThreshold},
+		{"HighGCThreshold", d.HighGCThreshold},
+		{"ShortSeqThreshold", d.ShortSeqThreshold},
+		{"LowGCKValues", d.LowGCKValues},
+		{"MedGCKValues", d.MedGCKValues},
+		{"HighGCKValues", d.HighGCKValues},
+		{"LowGCMaxErrors", d.LowGCMaxErrors},
+		{"HighGCMaxErrors", d.HighGCMaxErrors},
+		{"VeryShortSegmentKValues", d.VeryShortSegmentKValues},
+		{"ShortSegmentKValues", d.ShortSegmentKValues},
+		{"LongerSegmentKValues", d.LongerSegmentKValues},
+		{"BoundaryExtraErrors", d.BoundaryExtraErrors},
+		{"VeryShortSeqThreshold", d.VeryShortSeqThreshold},
+		{"VeryShortSeqMinMatchLength", d.VeryShortSeqMinMatchLength},
+		{"VeryShortSeqStride", d.VeryShortSeqStride},
+		{"VeryShortSeqKValues", d.VeryShortSeqKValues},
+		{"PairwiseMatchScore", d.PairwiseMatchScore},
+		{"PairwiseMismatchScore", d.PairwiseMismatchScore},
+		{"PairwiseGapOpen", d.PairwiseGapOpen},
+		{"PairwiseGapExtend", d.PairwiseGapExtend},
+		{"PairwiseBandWidth", d.PairwiseBandWidth},
+		{"PairwiseMaxCells", d.PairwiseMaxCells},
+		{"MapQMax", d.MapQMax},
+		{"MapQSeedK", d.MapQSeedK},
+		{"MapQLocusOverlap", d.MapQLocusOverlap},
+		{"MapQShortAnchorLen", d.MapQShortAnchorLen},
+	}
+	var sb strings.Builder
+	for _, p := range params {
+		fmt.Fprintf(&sb, "%s = %v\n", p.name, p.value)
+	}
+	golden.AssertString(t, sb.String())
+}
+
+func TestKValuesAreAscending(t *testing.T) {
+	d := Default()
+	for name, ks := range map[string][]int{
+		"LowGCKValues": d.LowGCKValues, "MedGCKValues": d.MedGCKValues, "HighGCKValues": d.HighGCKValues,
+		"VeryShortSegmentKValues": d.VeryShortSegmentKValues, "ShortSegmentKValues": d.ShortSegmentKValues,
+		"LongerSegmentKValues": d.LongerSegmentKValues, "VeryShortSeqKValues": d.VeryShortSeqKValues,
+	} {
+		if len(ks) == 0 || !sort.IntsAreSorted(ks) || ks[0] <= 0 {
+			t.Errorf("%s = %v: want a non-empty ascending list of positive k", name, ks)
+		}
+	}
+	if d.LowGCThreshold >= d.HighGCThreshold {
+		t.Errorf("LowGCThreshold %v must be below HighGCThreshold %v", d.LowGCThreshold, d.HighGCThreshold)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	var sb strings.Builder
+	for _, name := range PresetNames() {
+		p, err := Preset(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Validate(); err != nil {
+			t.Errorf("preset %s: %v", name, err)
+		}
+		fmt.Fprintf(&sb, "%s %s\n", name, p)
+	}
+	golden.AssertString(t, sb.String())
+
+	if _, err := Preset("nope"); err == nil {
+		t.Errorf("expected an error for an unknown preset")
+	}
+}
+
+func TestDefaultSharesNoSlices(t *testing.T) {
+	p := Default()
+	p.LowGCKValues[0] = 99
+	q := Default()
+	if q.LowGCKValues[0] == 99 {
+		t.Errorf("Default shares k-value slices between calls")
+	}
+	q.MedGCKValues = append(q.MedGCKValues[:1], 42)
+	if fmt.Sprint(Default().MedGCKValues) != "[7 8 9]" {
+		t.Errorf("appending to a k-value slice changed the defaults: %v", Default().MedGCKValues)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	p, err := Resolve("asm5", write("c.json", `{"min_match_length": 40, "low_gc_k_values": [11, 12]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MinMatchLength != 40 || fmt.Sprint(p.LowGCKValues) != "[11 12]" || p.MinIdentityThreshold != 0.9 {
+		t.Errorf("JSON overrides not applied on top of the preset: %s", p)
+	}
+
+	p, err = Resolve("", write("c.JSON", `{"min_match_length": 32, "high_gc_k_values": [5, 6]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MinMatchLength != 32 || fmt.Sprint(p.HighGCKValues) != "[5 6]" || p.DefaultK != Default().DefaultK {
+		t.Errorf("overrides not applied on top of the defaults: %s", p)
+	}
+
+	// A saved configuration loads back unchanged.
+	saved := write("saved.json", p.String())
+	if q, err := Resolve("", saved); err != nil || q.String() != p.String() {
+		t.Errorf("reloading %s: %v", saved, err)
+	}
+
+	for name, content := range map[string]string{
+		"unknown.json": `{"no_such_parameter": 1}`,
+		"invalid.json": `{"low_gc_k_values": [9, 8]}`,
+		"flat.toml":    "min_match_length = 32\n",
+		"flat.yaml":    "min_match_length: 32\n",
+	} {
+		if _, err := Resolve("", write(name, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := Resolve("", filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Params is the complete set of tunable parameters. The aligner receives them explicitly, so
+// alignments with different parameters can run side by side. The JSON keys are the names
+// used in configuration files and output headers; Default gives the built-in values.
+type Params struct {
+	DefaultK         int `json:"default_k"`
+	MinMatchLength   int `json:"min_match_length"`
+	DefaultMaxErrors int `json:"default_max_errors"`
+	DefaultStride    int `json:"default_stride"`
+
+	ExtendMaxErrors      int     `json:"extend_max_errors"`
+	MinIdentityThreshold float64 `json:"min_identity_threshold"`
+
+	DefaultOverlapThreshold     float64 `json:"default_overlap_threshold"`
+	HighQualityOverlapThreshold float64 `json:"high_quality_overlap_threshold"`
+
+	MaxSegmentSize            int     `json:"max_segment_size"`
+	LargeRegionChunkSize      int     `json:"large_region_chunk_size"`
+	LargeRegionOverlap        int     `json:"large_region_overlap"`
+	StandardChunkOverlapRatio float64 `json:"standard_chunk_overlap_ratio"`
+
+	VerySmallRegionThreshold int `json:"very_small_region_threshold"`
+	SmallKForShortSegments   int `json:"small_k_for_short_segments"`
+	MediumKForShortSegments  int `json:"medium_k_for_short_segments"`
+	LargeKForShortSegments   int `json:"large_k_for_short_segments"`
+
+	SmallSegmentLength   int `json:"small_segment_length"`
+	SamplePositionsCount int `json:"sample_positions_count"`
+
+	AdjacentMergeMaxGap   int     `json:"adjacent_merge_max_gap"`
+	FinalMergeMaxGap      int     `json:"final_merge_max_gap"`
+	MaxGapRatioDifference float64 `json:"max_gap_ratio_difference"`
+
+	LowGCThreshold    float64 `json:"low_gc_threshold"`
+	HighGCThreshold   float64 `json:"high_gc_threshold"`
+	ShortSeqThreshold int     `json:"short_seq_threshold"`
+	LowGCKValues      []int   `json:"low_gc_k_values"`
+	MedGCKValues      []int   `json:"med_gc_k_values"`
+	HighGCKValues     []int   `json:"high_gc_k_values"`
+	LowGCMaxErrors    int     `json:"low_gc_max_errors"`
+	HighGCMaxErrors   int     `json:"high_gc_max_errors"`
+
+	VeryShortSegmentKValues []int `json:"very_short_segment_k_values"`
+	ShortSegmentKValues     []int `json:"short_segment_k_values"`
+	LongerSegmentKValues    []int `json:"longer_segment_k_values"`
+	BoundaryExtraErrors     int   `json:"boundary_extra_errors"`
+
+	VeryShortSeqThreshold      int   `json:"very_short_seq_threshold"`
+	VeryShortSeqMinMatchLength int   `json:"very_short_seq_min_match_length"`
+	VeryShortSeqStride         int   `json:"very_short_seq_stride"`
+	VeryShortSeqKValues        []int `json:"very_short_seq_k_values"`
+
+	PairwiseMatchScore      int `json:"pairwise_match_score"`
+	PairwiseMismatchScore   int `json:"pairwise_mismatch_score"`
+	PairwiseTransitionScore int `json:"pairwise_transition_score"`
+	PairwiseAmbiguousScore  int `json:"pairwise_ambiguous_score"`
+	PairwiseGapOpen         int `json:"pairwise_gap_open"`
+	PairwiseGapExtend       int `json:"pairwise_gap_extend"`
+	PairwiseBandWidth       int `json:"pairwise_band_width"`
+	PairwiseMaxCells        int `json:"pairwise_max_cells"`
+
+	MapQMax            int     `json:"mapq_max"`
+	MapQSeedK          int     `json:"mapq_seed_k"`
+	MapQLocusOverlap   float64 `json:"mapq_locus_overlap"`
+	MapQShortAnchorLen int     `json:"mapq_short_anchor_len"`
+}
+
+// clone returns a copy of p that shares no slices with it.
+func (p Params) clone() Params {
+	for _, s := range []*[]int{&p.LowGCKValues, &p.MedGCKValues, &p.HighGCKValues, &p.VeryShortSegmentKValues,
+		&p.ShortSegmentKValues, &p.LongerSegmentKValues, &p.VeryShortSeqKValues} {
+		*s = append([]int(nil), (*s)...)
+	}
+	return p
+}
+
+// DefaultPreset is the name of the built-in parameter set.
+const DefaultPreset = "default"
+
+// presets derive each named parameter set from the defaults.
+var presets = map[string]func(*Params){
+	DefaultPreset: func(*Params) {},
+
+	// Assembly-to-assembly with up to ~5% divergence: long exact seeds, strict extension.
+	"asm5": func(p *Params) {
+		p.MinMatchLength = 50
+		p.DefaultMaxErrors, p.ExtendMaxErrors = 3, 3
+		p.MinIdentityThreshold = 0.9
+		p.LowGCKValues, p.MedGCKValues, p.HighGCKValues = []int{13, 15, 17}, []int{12, 14, 16}, []int{11, 13, 15}
+		p.LowGCMaxErrors, p.HighGCMaxErrors = 2, 3
+		p.PairwiseMismatchScore = -6
+	},
+
+	// Assembly-to-assembly with up to ~20% divergence.
+	"asm20": func(p *Params) {
+		p.MinMatchLength = 36
+		p.DefaultMaxErrors, p.ExtendMaxErrors = 6, 8
+		p.MinIdentityThreshold = 0.7
+		p.LowGCKValues, p.MedGCKValues, p.HighGCKValues = []int{9, 10, 11}, []int{8, 9, 10}, []int{7, 8, 9}
+		p.LowGCMaxErrors, p.HighGCMaxErrors = 5, 7
+		p.PairwiseMismatchScore = -3
+		p.PairwiseBandWidth = 64
+	},
+
+	// Short queries (a few hundred bases): short anchors, dense seeding, little merging.
+	"short-read": func(p *Params) {
+		p.MinMatchLength = 20
+		p.DefaultStride = 1
+		p.MaxSegmentSize = 150
+		p.SmallSegmentLength = 100
+		p.AdjacentMergeMaxGap, p.FinalMergeMaxGap = 12, 8
+		p.VeryShortSeqMinMatchLength = 16
+		p.MapQShortAnchorLen = 50
+	},
+
+	// Highly divergent sequences (~30%): small k, many errors, permissive identity and scoring.
+	"divergent": func(p *Params) {
+		p.MinMatchLength = 20
+		p.DefaultStride = 1
+		p.DefaultMaxErrors, p.ExtendMaxErrors = 8, 10
+		p.MinIdentityThreshold = 0.6
+		p.LowGCKValues, p.MedGCKValues, p.HighGCKValues = []int{6, 7, 8}, []int{5, 6, 7}, []int{5, 6, 7}
+		p.LowGCMaxErrors, p.HighGCMaxErrors = 7, 9
+		p.BoundaryExtraErrors = 4
+		p.PairwiseMismatchScore = -2
+		p.PairwiseBandWidth = 96
+	},
+}
+
+// PresetNames returns the names accepted by Preset, sorted.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Preset returns the named parameter set.
+func Preset(name string) (Params, error) {
+	adjust, ok := presets[name]
+	if !ok {
+		return Params{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(PresetNames(), ", "))
+	}
+	p := Default()
+	adjust(&p)
+	return p, nil
+}
+
+// LoadFile overrides the parameters in base with those set in the JSON configuration file at
+// path, which must end in .json. Keys missing from the file keep their base value; unknown keys
+// are an error.
+func LoadFile(path string, base Params) (Params, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		return base, fmt.Errorf("config file '%s': only JSON configuration files (.json) are supported", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base, fmt.Errorf("reading config file '%s': %w", path, err)
+	}
+
+	p := base.clone()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return base, fmt.Errorf("config file '%s': %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return base, fmt.Errorf("config file '%s': %w", path, err)
+	}
+	return p, nil
+}
+
+// Resolve returns the parameters selected by a preset name (empty for the default) and an
+// optional configuration file applied on top of it.
+func Resolve(preset, path string) (Params, error) {
+	if preset == "" {
+		preset = DefaultPreset
+	}
+	p, err := Preset(preset)
+	if err != nil {
+		return p, err
+	}
+	if path != "" {
+		return LoadFile(path, p)
+	}
+	return p, nil
+}
+
+// Validate checks that p is usable by the aligner.
+func (p Params) Validate() error {
+	for name, ks := range map[string][]int{
+		"low_gc_k_values": p.LowGCKValues, "med_gc_k_values": p.MedGCKValues, "high_gc_k_values": p.HighGCKValues,
+		"very_short_segment_k_values": p.VeryShortSegmentKValues, "short_segment_k_values": p.ShortSegmentKValues,
+		"longer_segment_k_values": p.LongerSegmentKValues, "very_short_seq_k_values": p.VeryShortSeqKValues,
+	} {
+		if len(ks) == 0 || !sort.IntsAreSorted(ks) || ks[0] <= 0 {
+			return fmt.Errorf("%s = %v: want a non-empty ascending list of positive k", name, ks)
+		}
+	}
+	if p.LowGCThreshold >= p.HighGCThreshold {
+		return fmt.Errorf("low_gc_threshold %v must be below high_gc_threshold %v", p.LowGCThreshold, p.HighGCThreshold)
+	}
+	for name, v := range map[string]int{
+		"default_k": p.DefaultK, "min_match_length": p.MinMatchLength, "default_stride": p.DefaultStride,
+		"very_short_seq_stride": p.VeryShortSeqStride, "max_segment_size": p.MaxSegmentSize,
+		"large_region_chunk_size": p.LargeRegionChunkSize, "sample_positions_count": p.SamplePositionsCount,
+		"pairwise_match_score": p.PairwiseMatchScore, "pairwise_band_width": p.PairwiseBandWidth,
+		"mapq_max": p.MapQMax, "mapq_seed_k": p.MapQSeedK, "mapq_short_anchor_len": p.MapQShortAnchorLen,
+	} {
+		if v <= 0 {
+			return fmt.Errorf("%s = %d: must be positive", name, v)
+		}
+	}
+	for name, v := range map[string]int{
+		"large_region_overlap": p.LargeRegionOverlap, "pairwise_gap_open": p.PairwiseGapOpen,
+		"pairwise_gap_extend": p.PairwiseGapExtend,
+	} {
+		if v < 0 {
+			return fmt.Errorf("%s = %d: must not be negative", name, v)
+		}
+	}
+	if p.LargeRegionOverlap >= p.LargeRegionChunkSize {
+		return fmt.Errorf("large_region_overlap %d must be below large_region_chunk_size %d", p.LargeRegionOverlap, p.LargeRegionChunkSize)
+	}
+	// Chunks of max_segment_size bases overlap by max_segment_size / standard_chunk_overlap_ratio.
+	if p.StandardChunkOverlapRatio <= 1 {
+		return fmt.Errorf("standard_chunk_overlap_ratio %v must be above 1", p.StandardChunkOverlapRatio)
+	}
+	if p.MinIdentityThreshold < 0 || p.MinIdentityThreshold > 1 {
+		return fmt.Errorf("min_identity_threshold %v must be between 0 and 1", p.MinIdentityThreshold)
+	}
+	return nil
+}
+
+// String returns p as compact JSON, which can be saved and loaded again with LoadFile.
+func (p Params) String() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return string(data)
+}
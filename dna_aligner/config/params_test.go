@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidate checks that Validate rejects values that would give a non-positive chunk stride or
+// a meaningless base-level alignment, naming the offending key.
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+	cases := []struct {
+		key    string
+		change func(*Params)
+	}{
+		{"standard_chunk_overlap_ratio", func(p *Params) { p.StandardChunkOverlapRatio = 0 }},
+		{"standard_chunk_overlap_ratio", func(p *Params) { p.StandardChunkOverlapRatio = -2 }},
+		{"standard_chunk_overlap_ratio", func(p *Params) { p.StandardChunkOverlapRatio = 1 }},
+		{"pairwise_gap_open", func(p *Params) { p.PairwiseGapOpen = -1 }},
+		{"pairwise_gap_extend", func(p *Params) { p.PairwiseGapExtend = -2 }},
+		{"pairwise_band_width", func(p *Params) { p.PairwiseBandWidth = -5 }},
+		{"pairwise_band_width", func(p *Params) { p.PairwiseBandWidth = 0 }},
+		{"large_region_overlap", func(p *Params) { p.LargeRegionOverlap = -1 }},
+		{"large_region_overlap", func(p *Params) { p.LargeRegionOverlap = p.LargeRegionChunkSize + 1 }},
+		{"large_region_overlap", func(p *Params) { p.LargeRegionOverlap = p.LargeRegionChunkSize }},
+	}
+	for _, c := range cases {
+		p := Default()
+		c.change(&p)
+		err := p.Validate()
+		if err == nil || !strings.Contains(err.Error(), c.key) {
+			t.Errorf("%s: got error %v, want one naming %s", c.key, err, c.key)
+		}
+	}
+
+	p := Default()
+	p.PairwiseGapOpen, p.LargeRegionOverlap = 0, 0
+	if err := p.Validate(); err != nil {
+		t.Errorf("zero gap open and overlap: %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package eval
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DefaultTolerance is the default for Options.Tolerance.
+const DefaultTolerance = 20
+
+// StructuralEventMinCorrect is the fraction of a structural event's query bases that must be
+// aligned correctly for the event to count as detected.
+const StructuralEventMinCorrect = 0.8
+
+// Options controls Evaluate.
+type Options struct {
+	// Tolerance is the largest distance, in bases, at which a predicted reference position
+	// or breakpoint still counts as correct. 0 selects DefaultTolerance.
+	Tolerance int
+}
+
+// Report holds the accuracy of a predicted mapping against the truth.
+// Base-level figures count query bases: a base is correct when both mappings align it,
+// the predicted reference position is within the tolerance and the strands agree.
+type Report struct {
+	QueryLength    int     `json:"query_length"`
+	TruthBases     int     `json:"truth_aligned_bases"`
+	PredictedBases int     `json:"predicted_aligned_bases"`
+	CorrectBases   int     `json:"correct_bases"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+
+	StrandComparedBases int `json:"strand_compared_bases"` // Aligned by both, with known strands
+	StrandErrorBases    int `json:"strand_error_bases"`
+
+	Breakpoints BreakpointStats       `json:"breakpoints"`
+	Events      map[string]EventStats `json:"events,omitempty"` // By event kind
+}
+
+// BreakpointStats measures how far each true breakpoint (a query position where the true
+// mapping jumps) is from the nearest predicted segment boundary.
+type BreakpointStats struct {
+	Count           int     `json:"count"`
+	WithinTolerance int     `json:"within_tolerance"`
+	MeanDistance    float64 `json:"mean_distance"`
+	MaxDistance     int     `json:"max_distance"`
+}
+
+// EventStats is the sensitivity for one kind of simulated event.
+type EventStats struct {
+	Total       int     `json:"total"`
+	Detected    int     `json:"detected"`
+	Sensitivity float64 `json:"sensitivity"`
+}
+
+// projection maps every query base to a reference position (-1 when unaligned) and strand.
+type projection struct {
+	ref    []int
+	strand []byte
+}
+
+// Evaluate compares pred with truth. events (optional) are the simulated mutations
+// for the per-kind sensitivity.
+func Evaluate(pred, truth Mapping, events []simulate.Event, opts Options) Report {
+	tol := opts.Tolerance
+	if tol <= 0 {
+		tol = DefaultTolerance
+	}
+	queryLen := max(pred.QueryLength, truth.QueryLength)
+	for _, m := range []Mapping{pred, truth} {
+		for _, iv := range m.Intervals {
+			queryLen = max(queryLen, iv.QueryEnd)
+		}
+	}
+
+	p, t := project(pred, queryLen), project(truth, queryLen)
+	correct := make([]bool, queryLen)
+	r := Report{QueryLength: queryLen}
+	for q := 0; q < queryLen; q++ {
+		if t.ref[q] >= 0 {
+			r.TruthBases++
+		}
+		if p.ref[q] < 0 {
+			continue
+		}
+		r.PredictedBases++
+		if t.ref[q] < 0 {
+			continue
+		}
+		strandsKnown := p.strand[q] != StrandUnknown && t.strand[q] != StrandUnknown
+		if strandsKnown {
+			r.StrandComparedBases++
+			if p.strand[q] != t.strand[q] {
+				r.StrandErrorBases++
+				continue
+			}
+		}
+		if abs(p.ref[q]-t.ref[q]) <= tol {
+			correct[q] = true
+			r.CorrectBases++
+		}
+	}
+	if r.PredictedBases > 0 {
+		r.Precision = float64(r.CorrectBases) / float64(r.PredictedBases)
+	}
+	if r.TruthBases > 0 {
+		r.Recall = float64(r.CorrectBases) / float64(r.TruthBases)
+	}
+	if r.Precision+r.Recall > 0 {
+		r.F1 = 2 * r.Precision * r.Recall / (r.Precision + r.Recall)
+	}
+
+	boundaries := boundaryPositions(pred)
+	distance := func(pos int) int {
+		if pos <= 0 || pos >= queryLen {
+			return 0 // The ends of the query are not breakpoints
+		}
+		i := sort.SearchInts(boundaries, pos)
+		best := queryLen
+		if i < len(boundaries) {
+			best = boundaries[i] - pos
+		}
+		if i > 0 {
+			best = min(best, pos-boundaries[i-1])
+		}
+		return best
+	}
+
+	total := 0
+	for _, pos := range truthBreakpoints(truth, queryLen) {
+		d := distance(pos)
+		r.Breakpoints.Count++
+		total += d
+		r.Breakpoints.MaxDistance = max(r.Breakpoints.MaxDistance, d)
+		if d <= tol {
+			r.Breakpoints.WithinTolerance++
+		}
+	}
+	if r.Breakpoints.Count > 0 {
+		r.Breakpoints.MeanDistance = float64(total) / float64(r.Breakpoints.Count)
+	}
+
+	if len(events) > 0 {
+		r.Events = make(map[string]EventStats)
+	}
+	for _, ev := range events {
+		st := r.Events[ev.Kind]
+		st.Total++
+		if eventDetected(ev, correct, distance, tol) {
+			st.Detected++
+		}
+		st.Sensitivity = float64(st.Detected) / float64(st.Total)
+		r.Events[ev.Kind] = st
+	}
+	return r
+}
+
+// eventDetected reports whether the predicted mapping recovers ev. Small variants need their
+// flanking bases (and, for a SNP, the base itself) aligned correctly; structural events need
+// most of their bases aligned correctly and predicted breakpoints near both ends.
+func eventDetected(ev simulate.Event, correct []bool, distance func(int) int, tol int) bool {
+	isCorrect := func(q int) bool { return q < 0 || q >= len(correct) || correct[q] }
+	switch ev.Kind {
+	case simulate.EventSNP:
+		return ev.QueryStart < len(correct) && correct[ev.QueryStart]
+	case simulate.EventInsertion, simulate.EventDeletion:
+		return isCorrect(ev.QueryStart-1) && isCorrect(ev.QueryEnd)
+	}
+	if ev.QueryEnd <= ev.QueryStart {
+		return false
+	}
+	n := 0
+	for q := ev.QueryStart; q < ev.QueryEnd && q < len(correct); q++ {
+		if correct[q] {
+			n++
+		}
+	}
+	return float64(n) >= StructuralEventMinCorrect*float64(ev.QueryEnd-ev.QueryStart) &&
+		distance(ev.QueryStart) <= tol && distance(ev.QueryEnd) <= tol
+}
+
+// project maps each query base of m to its reference position, using the CIGAR when present
+// and a straight line through the interval otherwise. Where intervals overlap the first one wins.
+func project(m Mapping, queryLen int) projection {
+	p := projection{ref: make([]int, queryLen), strand: make([]byte, queryLen)}
+	for i := range p.ref {
+		p.ref[i] = -1
+	}
+	set := func(q, r int, strand byte) {
+		if q >= 0 && q < queryLen && p.ref[q] < 0 {
+			p.ref[q], p.strand[q] = r, strand
+		}
+	}
+
+	for _, iv := range m.Intervals {
+		reverse := iv.Strand == StrandReverse
+		// queryAt returns the query position of the k-th base walked by the alignment.
+		queryAt := func(k int) int {
+			if reverse {
+				return iv.QueryEnd - 1 - k
+			}
+			return iv.QueryStart + k
+		}
+
+		if len(iv.Ops) == 0 {
+			qLen, rLen := iv.QueryEnd-iv.QueryStart, iv.RefEnd-iv.RefStart
+			for k := 0; k < qLen; k++ {
+				r := iv.RefStart + k*rLen/qLen
+				if reverse {
+					r = iv.RefEnd - 1 - k*rLen/qLen
+				}
+				set(iv.QueryStart+k, r, iv.Strand)
+			}
+			continue
+		}
+
+		k, r := 0, iv.RefStart
+		for _, op := range iv.Ops {
+			switch op.Kind {
+			case 'M', pairwise.OpMatch, pairwise.OpMismatch:
+				for j := 0; j < op.Len; j++ {
+					set(queryAt(k), r, iv.Strand)
+					k++
+					r++
+				}
+			case pairwise.OpInsertion:
+				k += op.Len
+			case pairwise.OpDeletion:
+				r += op.Len
+			}
+		}
+	}
+	return p
+}
+
+// boundaryPositions returns the sorted query start and end positions of the intervals of m.
+func boundaryPositions(m Mapping) []int {
+	var pos []int
+	for _, iv := range m.Intervals {
+		pos = append(pos, iv.QueryStart, iv.QueryEnd)
+	}
+	sort.Ints(pos)
+	return pos
+}
+
+// truthBreakpoints returns the sorted query positions where a true interval starts or ends,
+// excluding the query ends 0 and queryLen. An interval starting after 0 or ending before queryLen
+// leaves part of the query unaligned, so its outer boundary is a breakpoint too.
+func truthBreakpoints(truth Mapping, queryLen int) []int {
+	seen := make(map[int]bool)
+	var pos []int
+	for _, iv := range truth.Intervals {
+		for _, p := range []int{iv.QueryStart, iv.QueryEnd} {
+			if p > 0 && p < queryLen && !seen[p] {
+				seen[p] = true
+				pos = append(pos, p)
+			}
+		}
+	}
+	sort.Ints(pos)
+	return pos
+}
+
+// WriteText writes the report as tab-separated "name value" lines.
+func WriteText(w io.Writer, r Report) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "query_length\t%d\n", r.QueryLength)
+	fmt.Fprintf(bw, "truth_aligned_bases\t%d\n", r.TruthBases)
+	fmt.Fprintf(bw, "predicted_aligned_bases\t%d\n", r.PredictedBases)
+	fmt.Fprintf(bw, "correct_bases\t%d\n", r.CorrectBases)
+	fmt.Fprintf(bw, "precision\t%.4f\n", r.Precision)
+	fmt.Fprintf(bw, "recall\t%.4f\n", r.Recall)
+	fmt.Fprintf(bw, "f1\t%.4f\n", r.F1)
+	fmt.Fprintf(bw, "strand_errors\t%d/%d\n", r.StrandErrorBases, r.StrandComparedBases)
+	fmt.Fprintf(bw, "breakpoints\t%d (within tolerance %d, mean distance %.1f, max %d)\n",
+		r.Breakpoints.Count, r.Breakpoints.WithinTolerance, r.Breakpoints.MeanDistance, r.Breakpoints.MaxDistance)
+
+	kinds := make([]string, 0, len(r.Events))
+	for kind := range r.Events {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		st := r.Events[kind]
+		fmt.Fprintf(bw, "sensitivity.%s\t%.4f (%d/%d)\n", kind, st.Sensitivity, st.Detected, st.Total)
+	}
+	return bw.Flush()
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,137 @@
+package eval
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/aligner"
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/output"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// pafMapping writes segments as PAF and reads them back.
+func pafMapping(t *testing.T, res simulate.Result, segs []common.Segment, alns []pairwise.Alignment) Mapping {
+	t.Helper()
+	pair := output.Pair{QueryName: "q", Query: res.Query, RefName: "r", Ref: res.Ref}
+	var buf bytes.Buffer
+	if err := output.WritePAF(&buf, pair, segs, alns); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadMapping(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestReadMapping(t *testing.T) {
+	m, err := ReadMapping(strings.NewReader("[(0, 100, 5, 105), (100, 150, 300, 352)]\n"))
+	if err != nil || len(m.Intervals) != 2 || fmt.Sprint(m.Intervals[1]) != fmt.Sprint(Interval{QueryStart: 100, QueryEnd: 150, RefStart: 300, RefEnd: 352, Strand: StrandUnknown}) {
+		t.Errorf("tuples: got %+v, %v", m, err)
+	}
+	m, err = ReadMapping(strings.NewReader("q1\t[(0, 10, 0, 10)]\n"))
+	if err != nil || m.QueryName != "q1" || len(m.Intervals) != 1 {
+		t.Errorf("named tuples: got %+v, %v", m, err)
+	}
+	m, err = ReadMapping(strings.NewReader("q\t20\t0\t10\t-\tr\t50\t5\t16\t9\t11\t60\tcg:Z:4=1D2X4=\n"))
+	if err != nil || m.QueryLength != 20 || m.Intervals[0].Strand != StrandReverse || len(m.Intervals[0].Ops) != 4 {
+		t.Errorf("PAF: got %+v, %v", m, err)
+	}
+	for _, bad := range []string{"q\t20\t0\t10\t+\n", "q\t20\t0\t10\t\tr\t50\t5\t16\t9\t11\t60\n", "q\t20\t0\t10\t*\tr\t50\t5\t16\t9\t11\t60\n", "q\t20\t0\t10\t+\tr\t50\t5\t16\t9\t11\t60\tcg:Z:4S\n", "a\t1\t0\t1\t+\tr\t5\t0\t1\t1\t1\t0\nb\t1\t0\t1\t+\tr\t5\t0\t1\t1\t1\t0\n"} {
+		if _, err := ReadMapping(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestEvaluateTruthAgainstItself(t *testing.T) {
+	for _, sc := range simulate.Scenarios {
+		res := sc.Generate(1)
+		truth := pafMapping(t, res, res.Segments(), res.Alignments())
+		r := Evaluate(truth, truth, res.Events, Options{})
+		if r.Precision != 1 || r.Recall != 1 || r.StrandErrorBases != 0 || r.Breakpoints.WithinTolerance != r.Breakpoints.Count {
+			t.Errorf("%s: %+v", sc.Name, r)
+		}
+		for kind, st := range r.Events {
+			if st.Detected != st.Total {
+				t.Errorf("%s: %s detected %d/%d", sc.Name, kind, st.Detected, st.Total)
+			}
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	truth := Mapping{Intervals: []Interval{{QueryStart: 0, QueryEnd: 100, RefStart: 0, RefEnd: 100, Strand: StrandForward}}}
+	shifted := Mapping{Intervals: []Interval{{QueryStart: 0, QueryEnd: 100, RefStart: 30, RefEnd: 130, Strand: StrandUnknown}}}
+	if r := Evaluate(shifted, truth, nil, Options{Tolerance: 30}); r.CorrectBases != 100 {
+		t.Errorf("shift within tolerance: %d correct bases", r.CorrectBases)
+	}
+	if r := Evaluate(shifted, truth, nil, Options{Tolerance: 29}); r.CorrectBases != 0 || r.Precision != 0 {
+		t.Errorf("shift beyond tolerance: %+v", r)
+	}
+	reverse := Mapping{Intervals: []Interval{{QueryStart: 0, QueryEnd: 50, RefStart: 0, RefEnd: 50, Strand: StrandReverse}}}
+	if r := Evaluate(reverse, truth, nil, Options{}); r.StrandErrorBases != 50 || r.Recall != 0 {
+		t.Errorf("wrong strand: %+v", r)
+	}
+}
+
+// TestAlignerAccuracy checks that the aligner recovers most of the true mapping of every
+// scenario, nearly all of it on the right strand.
+func TestAlignerAccuracy(t *testing.T) {
+	common.LogWriter = io.Discard
+	for _, sc := range simulate.Scenarios {
+		res := sc.Generate(1)
+		aligned := aligner.Align(res.Query, res.Ref, aligner.Options{})
+		pred := pafMapping(t, res, aligned.Segments, aligned.Alignments)
+		truth := pafMapping(t, res, res.Segments(), res.Alignments())
+		r := Evaluate(pred, truth, res.Events, Options{})
+		if r.Precision < 0.9 || r.Recall < 0.9 {
+			t.Errorf("%s: precision %.4f, recall %.4f", sc.Name, r.Precision, r.Recall)
+		}
+		if r.StrandComparedBases == 0 || float64(r.StrandErrorBases) > 0.05*float64(r.StrandComparedBases) {
+			t.Errorf("%s: %d of %d bases on the wrong strand", sc.Name, r.StrandErrorBases, r.StrandComparedBases)
+		}
+	}
+}
+
+// TestBreakpointsAfterUnalignedPrefix checks that the boundaries of a truth mapping that leaves
+// both query ends unaligned count as breakpoints, and the query ends themselves do not.
+func TestBreakpointsAfterUnalignedPrefix(t *testing.T) {
+	truth := Mapping{QueryLength: 300, Intervals: []Interval{
+		{QueryStart: 40, QueryEnd: 150, RefStart: 0, RefEnd: 110, Strand: StrandForward},
+		{QueryStart: 150, QueryEnd: 260, RefStart: 500, RefEnd: 610, Strand: StrandForward},
+	}}
+	if got := truthBreakpoints(truth, 300); fmt.Sprint(got) != "[40 150 260]" {
+		t.Errorf("breakpoints %v, want [40 150 260]", got)
+	}
+	pred := Mapping{QueryLength: 300, Intervals: []Interval{{QueryStart: 0, QueryEnd: 300, RefStart: 0, RefEnd: 300, Strand: StrandForward}}}
+	if r := Evaluate(pred, truth, nil, Options{}); r.Breakpoints.Count != 3 || r.Breakpoints.WithinTolerance != 0 {
+		t.Errorf("one interval over the whole query: %+v", r.Breakpoints)
+	}
+	whole := Mapping{QueryLength: 300, Intervals: []Interval{{QueryStart: 0, QueryEnd: 300, RefStart: 0, RefEnd: 300, Strand: StrandForward}}}
+	if got := truthBreakpoints(whole, 300); len(got) != 0 {
+		t.Errorf("breakpoints of a single interval over the query: %v", got)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	truth := Mapping{QueryLength: 200, Intervals: []Interval{
+		{QueryStart: 0, QueryEnd: 100, RefStart: 0, RefEnd: 100, Strand: StrandForward},
+		{QueryStart: 100, QueryEnd: 200, RefStart: 300, RefEnd: 400, Strand: StrandReverse},
+	}}
+	pred := Mapping{QueryLength: 200, Intervals: []Interval{
+		{QueryStart: 0, QueryEnd: 120, RefStart: 0, RefEnd: 120, Strand: StrandForward},
+		{QueryStart: 120, QueryEnd: 200, RefStart: 300, RefEnd: 380, Strand: StrandReverse},
+	}}
+	events := []simulate.Event{{Kind: simulate.EventInversion, QueryStart: 100, QueryEnd: 200, RefStart: 300, RefEnd: 400}}
+	var buf bytes.Buffer
+	if err := WriteText(&buf, Evaluate(pred, truth, events, Options{})); err != nil {
+		t.Fatal(err)
+	}
+	golden.Assert(t, buf.Bytes())
+}
@@ -0,0 +1,158 @@
+// Package eval measures the accuracy of an alignment against a known truth mapping,
+// such as the one written by `dna_aligner simulate`.
+package eval
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Strand values of an Interval.
+const (
+	StrandForward = '+'
+	StrandReverse = '-'
+	StrandUnknown = '?' // Tuples output does not record the strand
+)
+
+// Interval is one aligned query/reference interval pair (0-based, half-open), as read from
+// tuples or PAF output. Ops holds the base-level alignment when the input carried a CIGAR.
+type Interval struct {
+	QueryStart int
+	QueryEnd   int
+	RefStart   int
+	RefEnd     int
+	Strand     byte
+	Ops        []pairwise.Op
+}
+
+// Mapping is the set of intervals reported for one query.
+type Mapping struct {
+	QueryName   string
+	QueryLength int // 0 when the input does not record it
+	Intervals   []Interval
+}
+
+var tupleRE = regexp.MustCompile(`\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)\s*\)`)
+
+// ReadMapping reads a tuples file (as written by `align -f tuples` and in the result files)
+// or a PAF file. Only a single query is supported.
+func ReadMapping(r io.Reader) (Mapping, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Mapping{}, err
+	}
+	text := strings.TrimSpace(string(data))
+	if i := strings.IndexByte(text, '['); i >= 0 && !strings.Contains(text[:i], "\n") {
+		return parseTuples(text[:i], text[i:])
+	}
+	return parsePAF(text)
+}
+
+// MappingFromSegments returns the mapping of segments (inclusive ends) and their base-level
+// alignments (see aligner.Result), as ReadMapping reads it back from PAF output.
+func MappingFromSegments(queryName string, queryLen int, segments []common.Segment, alignments []pairwise.Alignment) Mapping {
+	m := Mapping{QueryName: queryName, QueryLength: queryLen}
+	for i, seg := range segments {
+		iv := Interval{QueryStart: seg.QueryStart, QueryEnd: seg.QueryEnd + 1, RefStart: seg.RefStart, RefEnd: seg.RefEnd + 1, Strand: StrandUnknown}
+		if i < len(alignments) {
+			iv.Strand = StrandForward
+			if alignments[i].Reverse {
+				iv.Strand = StrandReverse
+			}
+			iv.Ops = alignments[i].Ops
+		}
+		m.Intervals = append(m.Intervals, iv)
+	}
+	return m
+}
+
+// parseTuples parses "[(qs, qe, rs, re), ...]", optionally preceded by a query name and a tab.
+func parseTuples(name, list string) (Mapping, error) {
+	m := Mapping{QueryName: strings.TrimSpace(name)}
+	if strings.Contains(strings.TrimSpace(list), "\n") {
+		return m, fmt.Errorf("tuples input holds more than one query")
+	}
+	for _, match := range tupleRE.FindAllStringSubmatch(list, -1) {
+		var v [4]int
+		for i := range v {
+			v[i], _ = strconv.Atoi(match[i+1])
+		}
+		m.Intervals = append(m.Intervals, Interval{QueryStart: v[0], QueryEnd: v[1], RefStart: v[2], RefEnd: v[3], Strand: StrandUnknown})
+	}
+	return m, nil
+}
+
+func parsePAF(text string) (Mapping, error) {
+	var m Mapping
+	for lineNo, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 12 {
+			return m, fmt.Errorf("line %d: expected at least 12 PAF columns, got %d", lineNo+1, len(fields))
+		}
+		if m.QueryName == "" {
+			m.QueryName = fields[0]
+		} else if fields[0] != m.QueryName {
+			return m, fmt.Errorf("line %d: more than one query (%s, %s)", lineNo+1, m.QueryName, fields[0])
+		}
+
+		var v [5]int
+		for i, col := range []int{1, 2, 3, 7, 8} {
+			n, err := strconv.Atoi(fields[col])
+			if err != nil {
+				return m, fmt.Errorf("line %d: column %d: %v", lineNo+1, col+1, err)
+			}
+			v[i] = n
+		}
+		if fields[4] != "+" && fields[4] != "-" {
+			return m, fmt.Errorf("line %d: strand %q is neither + nor -", lineNo+1, fields[4])
+		}
+		m.QueryLength = v[0]
+		iv := Interval{QueryStart: v[1], QueryEnd: v[2], RefStart: v[3], RefEnd: v[4], Strand: fields[4][0]}
+		for _, tag := range fields[12:] {
+			if cigar, ok := strings.CutPrefix(tag, "cg:Z:"); ok {
+				ops, err := parseCIGAR(cigar)
+				if err != nil {
+					return m, fmt.Errorf("line %d: %v", lineNo+1, err)
+				}
+				iv.Ops = ops
+			}
+		}
+		m.Intervals = append(m.Intervals, iv)
+	}
+	return m, nil
+}
+
+// parseCIGAR parses an (extended) CIGAR string. 'M' is read as a match/mismatch column.
+func parseCIGAR(cigar string) ([]pairwise.Op, error) {
+	var ops []pairwise.Op
+	n := 0
+	for i := 0; i < len(cigar); i++ {
+		c := cigar[i]
+		if c >= '0' && c <= '9' {
+			n = n*10 + int(c-'0')
+			continue
+		}
+		switch c {
+		case 'M', pairwise.OpMatch, pairwise.OpMismatch, pairwise.OpInsertion, pairwise.OpDeletion:
+		default:
+			return nil, fmt.Errorf("unsupported CIGAR operation %q", c)
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("CIGAR operation %q without length", c)
+		}
+		ops = append(ops, pairwise.Op{Kind: c, Len: n})
+		n = 0
+	}
+	if n != 0 {
+		return nil, fmt.Errorf("CIGAR %q ends with a length", cigar)
+	}
+	return ops, nil
+}
@@ -0,0 +1,33 @@
+package graph
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChainAnchors(t *testing.T) {
+	// Anchors sorted by query start; the heaviest non-overlapping chain is 0, 2, 4.
+	anchors := []common.AnchorMatch{
+		{QueryStart: 0, QueryEnd: 99, RefStart: 0, RefEnd: 99, Score: 100},
+		{QueryStart: 50, QueryEnd: 129, RefStart: 900, RefEnd: 979, Score: 60},
+		{QueryStart: 100, QueryEnd: 199, RefStart: 100, RefEnd: 199, Score: 95},
+		{QueryStart: 150, QueryEnd: 259, RefStart: 400, RefEnd: 509, Score: 90},
+		{QueryStart: 200, QueryEnd: 299, RefStart: 200, RefEnd: 299, Score: 98},
+	}
+	g := BuildSegmentGraph(anchors)
+
+	var sb strings.Builder
+	for node := -1; node <= len(anchors); node++ {
+		fmt.Fprintf(&sb, "%d ->", node)
+		for _, e := range g[node] {
+			fmt.Fprintf(&sb, " %d(%.0f)", e.To, e.Weight)
+		}
+		sb.WriteByte('\n')
+	}
+	fmt.Fprintf(&sb, "path %v\n", FindMaximumWeightPath(g, len(anchors)))
+	fmt.Fprintf(&sb, "empty path %v\n", FindMaximumWeightPath(BuildSegmentGraph(nil), 0))
+	golden.AssertString(t, sb.String())
+}
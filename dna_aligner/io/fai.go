@@ -0,0 +1,77 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FAIEntry is one line of a samtools-compatible FASTA index (.fai).
+type FAIEntry struct {
+	Name      string
+	Length    int64 // Number of bases
+	Offset    int64 // Byte offset of the first base
+	LineBases int   // Bases per line
+	LineWidth int   // Bytes per line, including the line terminator
+}
+
+// BuildFAI indexes FASTA input. Every sequence line of a record but the last must have the same
+// length, so that a base can be located from its position; blank lines may only end a record.
+func BuildFAI(r io.Reader) ([]FAIEntry, error) {
+	br := bufio.NewReaderSize(r, 1<<20)
+	var entries []FAIEntry
+	var cur *FAIEntry
+	var offset int64
+	lastLine := false // The current record had a shorter (final) line
+	for lineNo := 1; ; lineNo++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line == "" {
+			break
+		}
+		start := offset
+		offset += int64(len(line))
+		text := strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(text, ">"):
+			entries = append(entries, FAIEntry{Name: recordName(text), Offset: offset})
+			cur, lastLine = &entries[len(entries)-1], false
+		case cur == nil:
+			if strings.TrimSpace(text) != "" {
+				return nil, fmt.Errorf("line %d: expected '>' header, got %q", lineNo, truncate(text, 20))
+			}
+		case text == "":
+			lastLine = true
+		default:
+			if lastLine {
+				return nil, fmt.Errorf("line %d: different line length in sequence '%s'", lineNo, cur.Name)
+			}
+			if cur.Length == 0 {
+				cur.Offset, cur.LineBases, cur.LineWidth = start, len(text), len(line)
+			} else if len(text) > cur.LineBases || err == nil && len(line)-len(text) != cur.LineWidth-cur.LineBases {
+				return nil, fmt.Errorf("line %d: different line length in sequence '%s'", lineNo, cur.Name)
+			} else if len(text) < cur.LineBases {
+				lastLine = true
+			}
+			cur.Length += int64(len(text))
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// WriteFAI writes index entries in the .fai format.
+func WriteFAI(w io.Writer, entries []FAIEntry) error {
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", e.Name, e.Length, e.Offset, e.LineBases, e.LineWidth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package io
+
+import (
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecordReader(t *testing.T) {
+	var sb strings.Builder
+	for _, path := range []string{"testdata/records.fa", "testdata/records.fq"} {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := NewRecordReader(f)
+		for {
+			rec, err := rr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			fmt.Fprintf(&sb, "%s\t%s\t%s\n", path, rec.Name, rec.Seq)
+		}
+		f.Close()
+	}
+	golden.AssertString(t, sb.String())
+}
+
+func TestRecordReaderErrors(t *testing.T) {
+	for _, input := range []string{"ACGT\n", "@r1\nACGT\nIIII\n", "@r1\nACGT\n+\n"} {
+		if _, err := NewRecordReader(strings.NewReader(input)).Next(); err == nil || err == io.EOF {
+			t.Errorf("%q: expected a format error, got %v", input, err)
+		}
+	}
+}
+
+func TestWriteFASTA(t *testing.T) {
+	var sb strings.Builder
+	seq := strings.Repeat("ACGT", 40)
+	if err := WriteFASTA(&sb, "rec1", seq); err != nil {
+		t.Fatal(err)
+	}
+	golden.AssertString(t, sb.String())
+
+	rec, err := NewRecordReader(strings.NewReader(sb.String())).Next()
+	if err != nil || rec.Name != "rec1" || rec.Seq != seq {
+		t.Errorf("round trip gave %+v, %v", rec, err)
+	}
+}
+
+func TestBuildFAI(t *testing.T) {
+	f, err := os.Open("testdata/records.fa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	entries, err := BuildFAI(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := WriteFAI(&sb, entries); err != nil {
+		t.Fatal(err)
+	}
+	golden.AssertString(t, sb.String())
+
+	for _, bad := range []string{
+		">a\nACGT\nAC\nACGT\n", // short line inside the record
+		">a\nACGT\nACGTA\n",    // long line
+		">a\nACGT\n\nACGT\n",   // blank line inside the record
+		"ACGT\n",               // no header
+	} {
+		if _, err := BuildFAI(strings.NewReader(bad)); err == nil {
+			t.Errorf("BuildFAI(%q) returned no error", bad)
+		}
+	}
+	entries, err = BuildFAI(strings.NewReader(">a\r\nACGT\r\nAC"))
+	if err != nil || len(entries) != 1 || entries[0] != (FAIEntry{Name: "a", Length: 6, Offset: 4, LineBases: 4, LineWidth: 6}) {
+		t.Errorf("CRLF input without final newline: %+v, %v", entries, err)
+	}
+}
+
+func TestReadRecords(t *testing.T) {
+	var sb strings.Builder
+	for _, path := range []string{"testdata/plain.txt", "testdata/records.fa", "testdata/records.fq"} {
+		records, err := ReadRecords(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rec := range records {
+			fmt.Fprintf(&sb, "%s\t%s\t%s\n", path, rec.Name, rec.Seq)
+		}
+	}
+	golden.AssertString(t, sb.String())
+	if _, err := ReadRecord("testdata/missing.fa"); err == nil {
+		t.Error("ReadRecord of a missing file returned no error")
+	}
+}
@@ -1,15 +1,48 @@
 package io
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
-// ReadSequence reads a DNA sequence from a file.
-func ReadSequence(filePath string) (string, error) {
+// ReadRecords reads every record of a FASTA/FASTQ file. A plain sequence file yields one record
+// named after the file (without directory and extension), upper-cased and stripped of
+// whitespace like FASTA records.
+func ReadRecords(filePath string) ([]Record, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return strings.TrimSpace(string(data)), nil
+	text := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(text, ">") && !strings.HasPrefix(text, "@") {
+		stem := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+		return []Record{{Name: stem, Seq: strings.ToUpper(strings.Join(strings.Fields(text), ""))}}, nil
+	}
+	var records []Record
+	rr := NewRecordReader(strings.NewReader(text))
+	for {
+		rec, err := rr.Next()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading '%s': %w", filePath, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// ReadRecord reads the first record of a file as ReadRecords does.
+func ReadRecord(filePath string) (Record, error) {
+	records, err := ReadRecords(filePath)
+	if err != nil {
+		return Record{}, err
+	}
+	if len(records) == 0 {
+		return Record{}, fmt.Errorf("reading '%s': no sequence", filePath)
+	}
+	return records[0], nil
 }
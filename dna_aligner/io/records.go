@@ -0,0 +1,133 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Record is one named sequence from a FASTA or FASTQ file.
+type Record struct {
+	Name string
+	Seq  string
+}
+
+// RecordReader streams records from FASTA or FASTQ input, one at a time.
+// The format is detected from the first header character ('>' or '@').
+// Sequences are upper-cased and stripped of whitespace.
+type RecordReader struct {
+	br      *bufio.Reader
+	pending string // Header line read ahead while collecting the previous FASTA record
+	line    int
+}
+
+// NewRecordReader returns a reader for the FASTA/FASTQ records in r.
+func NewRecordReader(r io.Reader) *RecordReader {
+	return &RecordReader{br: bufio.NewReaderSize(r, 1<<20)}
+}
+
+// Next returns the next record, or io.EOF when the input is exhausted.
+func (rr *RecordReader) Next() (Record, error) {
+	header := rr.pending
+	rr.pending = ""
+	for header == "" {
+		line, err := rr.readLine()
+		if err != nil {
+			return Record{}, err
+		}
+		header = strings.TrimSpace(line)
+	}
+
+	switch header[0] {
+	case '>':
+		return rr.readFASTA(header)
+	case '@':
+		return rr.readFASTQ(header)
+	}
+	return Record{}, fmt.Errorf("line %d: expected '>' or '@' header, got %q", rr.line, truncate(header, 20))
+}
+
+func (rr *RecordReader) readFASTA(header string) (Record, error) {
+	rec := Record{Name: recordName(header)}
+	var seq bytes.Buffer
+	for {
+		line, err := rr.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Record{}, err
+		}
+		if strings.HasPrefix(line, ">") {
+			rr.pending = line
+			break
+		}
+		appendBases(&seq, line)
+	}
+	rec.Seq = seq.String()
+	return rec, nil
+}
+
+func (rr *RecordReader) readFASTQ(header string) (Record, error) {
+	rec := Record{Name: recordName(header)}
+	seqLine, err := rr.readLine()
+	if err != nil {
+		return Record{}, fmt.Errorf("line %d: truncated FASTQ record %q", rr.line, rec.Name)
+	}
+	plus, err := rr.readLine()
+	if err != nil || !strings.HasPrefix(plus, "+") {
+		return Record{}, fmt.Errorf("line %d: expected '+' separator in FASTQ record %q", rr.line, rec.Name)
+	}
+	if _, err := rr.readLine(); err != nil { // Qualities are not used
+		return Record{}, fmt.Errorf("line %d: missing qualities in FASTQ record %q", rr.line, rec.Name)
+	}
+	var seq bytes.Buffer
+	appendBases(&seq, seqLine)
+	rec.Seq = seq.String()
+	return rec, nil
+}
+
+// readLine returns the next line without its terminator. A final line without newline is returned as is.
+func (rr *RecordReader) readLine() (string, error) {
+	line, err := rr.br.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	rr.line++
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// recordName returns the first word of a header line without its '>' or '@' marker.
+func recordName(header string) string {
+	fields := strings.Fields(header[1:])
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// appendBases appends the non-whitespace characters of line to seq, upper-cased.
+func appendBases(seq *bytes.Buffer, line string) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			continue
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		}
+		seq.WriteByte(c)
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,21 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// FASTALineWidth is the number of bases per sequence line written by WriteFASTA.
+const FASTALineWidth = 60
+
+// WriteFASTA writes one FASTA record with the sequence wrapped at FASTALineWidth bases.
+func WriteFASTA(w io.Writer, name, seq string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, ">%s\n", name)
+	for i := 0; i < len(seq); i += FASTALineWidth {
+		bw.WriteString(seq[i:min(i+FASTALineWidth, len(seq))])
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -3,25 +3,26 @@ package matching
 import (
 	"DNA-Sequence-Alignments/dna_aligner/common"
 	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
 	"DNA-Sequence-Alignments/dna_aligner/sequence"
 	"math"
 	"sort"
 )
 
 // FindAnchors finds anchor regions between query and reference.
-// K, minMatchLen, stride, maxErrors: if 0, use config defaults.
-func FindAnchors(query, ref string, k, minMatchLen, stride, maxErrors int) []common.AnchorMatch {
+// K, minMatchLen, stride, maxErrors: if 0, use the values in p.
+func FindAnchors(query, ref string, p *config.Params, scheme *scoring.Scheme, k, minMatchLen, stride, maxErrors int) []common.AnchorMatch {
 	if k == 0 {
-		k = config.DefaultK
+		k = p.DefaultK
 	}
 	if minMatchLen == 0 {
-		minMatchLen = config.MinMatchLength
+		minMatchLen = p.MinMatchLength
 	}
 	if stride == 0 {
-		stride = config.DefaultStride
+		stride = p.DefaultStride
 	}
 	if maxErrors == 0 {
-		maxErrors = config.DefaultMaxErrors
+		maxErrors = p.DefaultMaxErrors
 	}
 	if k <= 0 {
 		return []common.AnchorMatch{}
@@ -39,7 +40,7 @@ func FindAnchors(query, ref string, k, minMatchLen, stride, maxErrors int) []com
 			continue
 		}
 
-		anchor := ExtendMatch(query, ref, em.QueryPos, em.RefPos, em.Length, minMatchLen, maxErrors)
+		anchor := ExtendMatch(query, ref, p, scheme, em.QueryPos, em.RefPos, em.Length, minMatchLen, maxErrors)
 		if anchor != nil {
 			anchors = append(anchors, *anchor)
 
@@ -63,7 +64,7 @@ func FindAnchors(query, ref string, k, minMatchLen, stride, maxErrors int) []com
 			}
 		}
 	}
-	return FilterAnchors(anchors, config.DefaultOverlapThreshold)
+	return FilterAnchors(anchors, p.DefaultOverlapThreshold)
 }
 
 // FilterAnchors filters and prioritizes anchors based on quality and overlap.
@@ -135,10 +136,10 @@ func FilterAnchors(anchors []common.AnchorMatch, overlapThreshold float64) []com
 }
 
 // FindReverseAnchors finds anchors between query and reverse complement of reference.
-func FindReverseAnchors(query, ref string, k, minMatchLen, stride, maxErrors int) []common.AnchorMatch {
+func FindReverseAnchors(query, ref string, p *config.Params, scheme *scoring.Scheme, k, minMatchLen, stride, maxErrors int) []common.AnchorMatch {
 	revRef := sequence.ReverseComplement(ref)
 	// FindAnchors returns anchors with coordinates relative to query and revRef.
-	anchorsOnRevRef := FindAnchors(query, revRef, k, minMatchLen, stride, maxErrors)
+	anchorsOnRevRef := FindAnchors(query, revRef, p, scheme, k, minMatchLen, stride, maxErrors)
 
 	var reverseAnchors []common.AnchorMatch
 	refOriginalLen := len(ref)
@@ -3,41 +3,65 @@ package matching
 import (
 	"DNA-Sequence-Alignments/dna_aligner/common"
 	"DNA-Sequence-Alignments/dna_aligner/config"
-	"math"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
 )
 
 // ExtendMatch extends a k-mer match to a longer anchor with error tolerance.
+// The walk is greedy: a mismatch first tries to resync through an indel of up to 2 bases, and each
+// direction stops once its errors exceed the budget. The anchor is scored with the scoring scheme
+// (substitution matrix and affine gaps) and its identity is matches over alignment columns.
 // Returns an AnchorMatch with inclusive coordinates if a valid extension is found, otherwise nil.
-func ExtendMatch(query, ref string, qStartKmer, rStartKmer, k int, minMatchLengthUser int, maxErrorsUser int) *common.AnchorMatch {
+func ExtendMatch(query, ref string, p *config.Params, scheme *scoring.Scheme, qStartKmer, rStartKmer, k int, minMatchLengthUser int, maxErrorsUser int) *common.AnchorMatch {
 	minMatchLen := minMatchLengthUser
 	if minMatchLen == 0 {
-		minMatchLen = config.MinMatchLength
+		minMatchLen = p.MinMatchLength
 	}
 	maxErrors := maxErrorsUser
 	if maxErrors == 0 {
-		maxErrors = config.ExtendMaxErrors
+		maxErrors = p.ExtendMaxErrors
+	}
+
+	// Alignment statistics of the walk, starting with the k-mer itself.
+	score, matches, columns := 0, 0, k
+	for i := 0; i < k; i++ {
+		score += scheme.Score(query[qStartKmer+i], ref[rStartKmer+i])
+		if scoring.IsMatch(query[qStartKmer+i], ref[rStartKmer+i]) {
+			matches++
+		}
+	}
+	// diagonal and gap account for one step of the walk.
+	diagonal := func(q, r int) {
+		score += scheme.Score(query[q], ref[r])
+		columns++
+		if scoring.IsMatch(query[q], ref[r]) {
+			matches++
+		}
+	}
+	gap := func(length int) {
+		score -= scheme.Gap(length)
+		columns += length
 	}
 
 	// Initial state from k-mer (exclusive ends for loop variables)
 	qCurrentFwd, rCurrentFwd := qStartKmer+k, rStartKmer+k
-	totalMatches := k
 	errorsFwd := 0
 
 	// Extend forward
 	for qCurrentFwd < len(query) && rCurrentFwd < len(ref) && errorsFwd <= maxErrors {
-		if query[qCurrentFwd] == ref[rCurrentFwd] {
+		if scoring.IsMatch(query[qCurrentFwd], ref[rCurrentFwd]) {
+			diagonal(qCurrentFwd, rCurrentFwd)
 			qCurrentFwd++
 			rCurrentFwd++
-			totalMatches++
 		} else {
 			indelMatchFound := false
 			// Try insertion in query (gap in ref)
 			for ins := 1; ins <= 2; ins++ {
-				if qCurrentFwd+ins < len(query) && rCurrentFwd < len(ref) && query[qCurrentFwd+ins] == ref[rCurrentFwd] {
+				if qCurrentFwd+ins < len(query) && rCurrentFwd < len(ref) && scoring.IsMatch(query[qCurrentFwd+ins], ref[rCurrentFwd]) {
+					gap(ins)
+					diagonal(qCurrentFwd+ins, rCurrentFwd) // The matching base after the indel
 					qCurrentFwd += (ins + 1)
 					rCurrentFwd++
-					errorsFwd++    // Indel costs 1 error
-					totalMatches++ // The matching base after indel
+					errorsFwd++ // Indel costs 1 error
 					indelMatchFound = true
 					break
 				}
@@ -48,11 +72,12 @@ func ExtendMatch(query, ref string, qStartKmer, rStartKmer, k int, minMatchLengt
 
 			// Try insertion in reference (gap in query)
 			for ins := 1; ins <= 2; ins++ {
-				if rCurrentFwd+ins < len(ref) && qCurrentFwd < len(query) && query[qCurrentFwd] == ref[rCurrentFwd+ins] {
+				if rCurrentFwd+ins < len(ref) && qCurrentFwd < len(query) && scoring.IsMatch(query[qCurrentFwd], ref[rCurrentFwd+ins]) {
+					gap(ins)
+					diagonal(qCurrentFwd, rCurrentFwd+ins)
 					qCurrentFwd++
 					rCurrentFwd += (ins + 1)
 					errorsFwd++
-					totalMatches++
 					indelMatchFound = true
 					break
 				}
@@ -62,6 +87,7 @@ func ExtendMatch(query, ref string, qStartKmer, rStartKmer, k int, minMatchLengt
 			}
 
 			// Mismatch
+			diagonal(qCurrentFwd, rCurrentFwd)
 			qCurrentFwd++
 			rCurrentFwd++
 			errorsFwd++
@@ -74,19 +100,20 @@ func ExtendMatch(query, ref string, qStartKmer, rStartKmer, k int, minMatchLengt
 	errorsBwd := 0 // Reset error count for backward extension, as in Python
 
 	for qCurrentBwd >= 0 && rCurrentBwd >= 0 && errorsBwd <= maxErrors {
-		if query[qCurrentBwd] == ref[rCurrentBwd] {
+		if scoring.IsMatch(query[qCurrentBwd], ref[rCurrentBwd]) {
+			diagonal(qCurrentBwd, rCurrentBwd)
 			qCurrentBwd--
 			rCurrentBwd--
-			totalMatches++
 		} else {
 			indelMatchFound := false
 			// Try insertion in query (gap in ref) - looking backward
 			for ins := 1; ins <= 2; ins++ {
-				if qCurrentBwd-ins >= 0 && rCurrentBwd >= 0 && query[qCurrentBwd-ins] == ref[rCurrentBwd] {
+				if qCurrentBwd-ins >= 0 && rCurrentBwd >= 0 && scoring.IsMatch(query[qCurrentBwd-ins], ref[rCurrentBwd]) {
+					gap(ins)
+					diagonal(qCurrentBwd-ins, rCurrentBwd)
 					qCurrentBwd -= (ins + 1)
 					rCurrentBwd--
 					errorsBwd++
-					totalMatches++
 					indelMatchFound = true
 					break
 				}
@@ -97,11 +124,12 @@ func ExtendMatch(query, ref string, qStartKmer, rStartKmer, k int, minMatchLengt
 
 			// Try insertion in reference (gap in query) - looking backward
 			for ins := 1; ins <= 2; ins++ {
-				if rCurrentBwd-ins >= 0 && qCurrentBwd >= 0 && query[qCurrentBwd] == ref[rCurrentBwd-ins] {
+				if rCurrentBwd-ins >= 0 && qCurrentBwd >= 0 && scoring.IsMatch(query[qCurrentBwd], ref[rCurrentBwd-ins]) {
+					gap(ins)
+					diagonal(qCurrentBwd, rCurrentBwd-ins)
 					qCurrentBwd--
 					rCurrentBwd -= (ins + 1)
 					errorsBwd++
-					totalMatches++
 					indelMatchFound = true
 					break
 				}
@@ -111,6 +139,7 @@ func ExtendMatch(query, ref string, qStartKmer, rStartKmer, k int, minMatchLengt
 			}
 
 			// Mismatch
+			diagonal(qCurrentBwd, rCurrentBwd)
 			qCurrentBwd--
 			rCurrentBwd--
 			errorsBwd++
@@ -129,35 +158,14 @@ func ExtendMatch(query, ref string, qStartKmer, rStartKmer, k int, minMatchLengt
 		return nil
 	}
 
-	identity := float64(totalMatches) / float64(matchLength)
-
-	// Context adjustment from Python (applied to identity)
-	if matchLength > 50 {
-		contextSize := int(math.Min(20, float64(matchLength/4)))
-		if finalQStart >= contextSize && finalRStart >= contextSize {
-			leftQ := query[finalQStart-contextSize : finalQStart]
-			leftR := ref[finalRStart-contextSize : finalRStart]
-			leftMatchesCount := 0
-			for i := 0; i < contextSize; i++ {
-				if leftQ[i] == leftR[i] {
-					leftMatchesCount++
-				}
-			}
-			identity = (identity*float64(matchLength) + float64(leftMatchesCount)*0.5) / (float64(matchLength) + float64(contextSize)*0.5)
-		}
-	}
-
-	// Python's scoring used the `errors` variable which, due to scope, was the one from backward extension (`errorsBwd`).
-	scoreRelevantErrors := errorsBwd
-
-	if matchLength >= minMatchLen && identity >= config.MinIdentityThreshold {
-		score := float64(matchLength) * identity * (1.0 - 0.05*float64(scoreRelevantErrors))
+	identity := float64(matches) / float64(columns)
+	if matchLength >= minMatchLen && identity >= p.MinIdentityThreshold {
 		return &common.AnchorMatch{
 			QueryStart: finalQStart,
 			QueryEnd:   finalQEndExclusive - 1, // Store inclusive end
 			RefStart:   finalRStart,
 			RefEnd:     finalREndExclusive - 1, // Store inclusive end
-			Score:      score,
+			Score:      float64(score),
 			Identity:   identity,
 		}
 	}
@@ -2,14 +2,10 @@ package matching
 
 import (
 	"DNA-Sequence-Alignments/dna_aligner/common"
-	"DNA-Sequence-Alignments/dna_aligner/config"
 )
 
 // FindExactMatches finds exact matches of length k between query and reference.
 func FindExactMatches(query, ref string, k int) []common.KmerMatch {
-	if k == 0 {
-		k = config.DefaultK
-	}
 	if k <= 0 || k > len(ref) || k > len(query) { // Basic validation
 		return []common.KmerMatch{}
 	}
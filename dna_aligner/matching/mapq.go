@@ -0,0 +1,76 @@
+package matching
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"math"
+)
+
+// RepeatIndex counts how often each k-mer occurs in the reference (both strands).
+// It is used to measure how repetitive the seeds behind an anchor are.
+type RepeatIndex struct {
+	k      int
+	counts map[string]int
+}
+
+// NewRepeatIndex indexes all k-mers of ref and its reverse complement. k is normally
+// Params.MapQSeedK.
+func NewRepeatIndex(ref string, k int) *RepeatIndex {
+	idx := &RepeatIndex{k: k, counts: make(map[string]int)}
+	for _, s := range []string{ref, sequence.ReverseComplement(ref)} {
+		for i := 0; i+k <= len(s); i++ {
+			idx.counts[s[i:i+k]]++
+		}
+	}
+	return idx
+}
+
+// RepeatFraction returns the fraction of the k-mers of seq that occur more than once in the reference.
+// A nil index reports no repetitiveness.
+func (idx *RepeatIndex) RepeatFraction(seq string) float64 {
+	if idx == nil || len(seq) < idx.k {
+		return 0.0
+	}
+	total, repetitive := 0, 0
+	for i := 0; i+idx.k <= len(seq); i++ {
+		total++
+		if idx.counts[seq[i:i+idx.k]] > 1 {
+			repetitive++
+		}
+	}
+	return float64(repetitive) / float64(total)
+}
+
+// EstimateMapQ computes a MAPQ-like value for anchor from the best competing placement
+// among candidates and the repetitiveness of its seeds.
+// Candidates compete when they cover at least p.MapQLocusOverlap of the anchor's query span
+// but lie at a different reference locus; the anchor itself may be among them.
+func EstimateMapQ(anchor common.AnchorMatch, candidates []common.AnchorMatch, repeatFraction float64, p *config.Params) int {
+	best := anchor.Score
+	if best <= 0 {
+		return 0
+	}
+
+	secondBest := 0.0
+	anchorQLen := anchor.QueryEnd - anchor.QueryStart + 1
+	anchorRLen := anchor.RefEnd - anchor.RefStart + 1
+	for _, cand := range candidates {
+		qOverlap := min(anchor.QueryEnd, cand.QueryEnd) - max(anchor.QueryStart, cand.QueryStart) + 1
+		if float64(qOverlap) < p.MapQLocusOverlap*float64(anchorQLen) {
+			continue
+		}
+		rOverlap := min(anchor.RefEnd, cand.RefEnd) - max(anchor.RefStart, cand.RefStart) + 1
+		if float64(rOverlap) >= p.MapQLocusOverlap*float64(anchorRLen) {
+			continue // Same locus (e.g. the anchor itself found with another k)
+		}
+		secondBest = math.Max(secondBest, cand.Score)
+	}
+
+	mapq := float64(p.MapQMax) * (1.0 - math.Min(1.0, secondBest/best))
+	mapq *= 1.0 - repeatFraction
+	if anchorQLen < p.MapQShortAnchorLen {
+		mapq *= float64(anchorQLen) / float64(p.MapQShortAnchorLen)
+	}
+	return int(math.Max(0, math.Min(float64(p.MapQMax), math.Round(mapq))))
+}
@@ -0,0 +1,128 @@
+package matching
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func formatAnchors(anchors []common.AnchorMatch) string {
+	var sb strings.Builder
+	for _, a := range anchors {
+		fmt.Fprintf(&sb, "q[%d,%d] r[%d,%d] score=%.2f id=%.4f\n", a.QueryStart, a.QueryEnd, a.RefStart, a.RefEnd, a.Score, a.Identity)
+	}
+	return sb.String()
+}
+
+func TestFindExactMatches(t *testing.T) {
+	var sb strings.Builder
+	for _, m := range FindExactMatches("ACGTACGTTT", "TTACGTACG", 4) {
+		fmt.Fprintf(&sb, "q%d r%d len%d\n", m.QueryPos, m.RefPos, m.Length)
+	}
+	golden.AssertString(t, sb.String())
+}
+
+// TestFindAnchorsOnTrueBlocks checks that every anchor passes the length and identity cutoffs
+// and that each long, unduplicated true block of every scenario shares at least 100 query bases with an
+// anchor on its own diagonal, found by the search of the block's strand.
+func TestFindAnchorsOnTrueBlocks(t *testing.T) {
+	const tolerance = 10 // Bases an indel near the anchor may shift its diagonal by
+	p := config.Default()
+	scheme := scoring.FromParams(&p)
+	for _, sc := range simulate.Scenarios {
+		res := sc.Generate(1)
+		forward := FindAnchors(res.Query, res.Ref, &p, scheme, 0, 0, 0, 0)
+		reverse := FindReverseAnchors(res.Query, res.Ref, &p, scheme, 0, 0, 0, 0)
+		for _, a := range append(append([]common.AnchorMatch{}, forward...), reverse...) {
+			if a.QueryEnd-a.QueryStart+1 < p.MinMatchLength || a.Identity < p.MinIdentityThreshold {
+				t.Errorf("%s: anchor %+v is below the length or identity cutoff", sc.Name, a)
+			}
+		}
+		for i, b := range res.Blocks {
+			if b.QueryEnd-b.QueryStart < 200 || sharesReference(res.Blocks, i) {
+				continue
+			}
+			anchors := forward
+			if b.Reverse {
+				anchors = reverse
+			}
+			found := false
+			for _, a := range anchors {
+				if min(a.QueryEnd+1, b.QueryEnd)-max(a.QueryStart, b.QueryStart) < 100 {
+					continue
+				}
+				// Forward blocks keep ref-query constant, reverse blocks ref+query.
+				want, got := b.RefStart-b.QueryStart, a.RefStart-a.QueryStart
+				if b.Reverse {
+					want, got = b.RefEnd-1+b.QueryStart, a.RefEnd+a.QueryStart
+				}
+				if abs(got-want) <= tolerance {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("%s: no anchor on block %+v", sc.Name, b)
+			}
+		}
+	}
+}
+
+// sharesReference reports whether another block copies reference bases of blocks[i]. Filtering
+// keeps a single anchor per reference locus, so only one copy is guaranteed an anchor.
+func sharesReference(blocks []simulate.Block, i int) bool {
+	for j, b := range blocks {
+		if j != i && b.RefStart < blocks[i].RefEnd && blocks[i].RefStart < b.RefEnd {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func TestEstimateMapQ(t *testing.T) {
+	anchor := common.AnchorMatch{QueryStart: 0, QueryEnd: 199, RefStart: 1000, RefEnd: 1199, Score: 200}
+	cases := []struct {
+		name           string
+		anchor         common.AnchorMatch
+		candidates     []common.AnchorMatch
+		repeatFraction float64
+	}{
+		{"unique", anchor, []common.AnchorMatch{anchor}, 0},
+		{"same locus other k", anchor, []common.AnchorMatch{anchor, {QueryStart: 5, QueryEnd: 190, RefStart: 1005, RefEnd: 1190, Score: 180}}, 0},
+		{"weaker second locus", anchor, []common.AnchorMatch{anchor, {QueryStart: 0, QueryEnd: 199, RefStart: 5000, RefEnd: 5199, Score: 120}}, 0},
+		{"equal second locus", anchor, []common.AnchorMatch{anchor, {QueryStart: 0, QueryEnd: 199, RefStart: 5000, RefEnd: 5199, Score: 200}}, 0},
+		{"repetitive seeds", anchor, []common.AnchorMatch{anchor}, 0.5},
+		{"short anchor", common.AnchorMatch{QueryStart: 0, QueryEnd: 39, RefStart: 10, RefEnd: 49, Score: 40}, nil, 0},
+		{"zero score", common.AnchorMatch{QueryStart: 0, QueryEnd: 39, RefStart: 10, RefEnd: 49}, nil, 0},
+	}
+	p := config.Default()
+	var sb strings.Builder
+	for _, c := range cases {
+		fmt.Fprintf(&sb, "%s: %d\n", c.name, EstimateMapQ(c.anchor, c.candidates, c.repeatFraction, &p))
+	}
+	golden.AssertString(t, sb.String())
+}
+
+func TestRepeatFraction(t *testing.T) {
+	ref := "ACGTACGTACGTTTGCAGGCATCA"
+	idx := NewRepeatIndex(ref, 4)
+	var sb strings.Builder
+	for _, seq := range []string{"ACGTACGT", "TTGCAGGC", "AC"} {
+		fmt.Fprintf(&sb, "%s %.3f\n", seq, idx.RepeatFraction(seq))
+	}
+	var none *RepeatIndex
+	fmt.Fprintf(&sb, "nil index %.3f\n", none.RepeatFraction(ref))
+	golden.AssertString(t, sb.String())
+}
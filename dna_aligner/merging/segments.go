@@ -2,14 +2,14 @@ package merging
 
 import (
 	"DNA-Sequence-Alignments/dna_aligner/common"
-	"DNA-Sequence-Alignments/dna_aligner/config"
 	"math"
 )
 
 // MergeAdjacentSegments merges adjacent or nearly adjacent segments.
 // Input segments MUST be sorted by QueryStart.
 // Segments are (q_start, q_end, r_start, r_end) inclusive.
-func MergeAdjacentSegments(segments []common.Segment, maxGapUser int) []common.Segment {
+// maxGapRatioDifference is normally Params.MaxGapRatioDifference.
+func MergeAdjacentSegments(segments []common.Segment, maxGapUser int, maxGapRatioDifference float64) []common.Segment {
 	if len(segments) <= 1 { // No merging needed for 0 or 1 segment
 		// Return a copy to avoid modifying input if it's a slice header from elsewhere
 		result := make([]common.Segment, len(segments))
@@ -44,7 +44,7 @@ func MergeAdjacentSegments(segments []common.Segment, maxGapUser int) []common.S
 			// maxDiffAllowed: max of 5 or a ratio of the smaller gap.
 			// If minActualGapVal is negative (overlap), its product with ratio is also negative.
 			// max(5, negative_value) = 5. So for overlaps, diff must be <=5.
-			maxDiffAllowed := math.Max(5.0, minActualGapVal*config.MaxGapRatioDifference)
+			maxDiffAllowed := math.Max(5.0, minActualGapVal*maxGapRatioDifference)
 			if math.Abs(float64(qGap-rGap)) <= maxDiffAllowed {
 				canMerge = true
 			}
@@ -52,8 +52,14 @@ func MergeAdjacentSegments(segments []common.Segment, maxGapUser int) []common.S
 
 		if canMerge {
 			// Merge: extend currentMerged segment's end to nextSegToConsider's end
+			// Identity is weighted by query length; provenance and MAPQ take the weaker of the two parts.
+			currLen := float64(currentMerged.QueryEnd - currentMerged.QueryStart + 1)
+			nextLen := float64(nextSegToConsider.QueryEnd - nextSegToConsider.QueryStart + 1)
+			currentMerged.Identity = (currentMerged.Identity*currLen + nextSegToConsider.Identity*nextLen) / (currLen + nextLen)
 			currentMerged.QueryEnd = nextSegToConsider.QueryEnd
 			currentMerged.RefEnd = nextSegToConsider.RefEnd
+			currentMerged.MapQ = min(currentMerged.MapQ, nextSegToConsider.MapQ)
+			currentMerged.Source = max(currentMerged.Source, nextSegToConsider.Source)
 		} else {
 			// No merge, add nextSegToConsider as a new segment to the merged list
 			merged = append(merged, nextSegToConsider)
@@ -0,0 +1,27 @@
+package merging
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMergeAdjacentSegments(t *testing.T) {
+	segs := []common.Segment{
+		{QueryStart: 0, QueryEnd: 99, RefStart: 0, RefEnd: 99, MapQ: 60, Source: common.SourceAnchor, Identity: 1.0},
+		{QueryStart: 110, QueryEnd: 199, RefStart: 112, RefEnd: 201, MapQ: 40, Source: common.SourceRegionRescan, Identity: 0.9}, // Consistent gap of 10
+		{QueryStart: 230, QueryEnd: 299, RefStart: 232, RefEnd: 301, MapQ: 60, Source: common.SourceAnchor, Identity: 1.0},       // Consistent gap of 30
+		{QueryStart: 300, QueryEnd: 399, RefStart: 900, RefEnd: 999, MapQ: 60, Source: common.SourceAnchor, Identity: 1.0},       // Jump in the reference
+		{QueryStart: 400, QueryEnd: 449, RefStart: 1000, RefEnd: 1049, MapQ: 0, Source: common.SourceModuloFallback, Identity: 0.5},
+	}
+	var sb strings.Builder
+	for _, maxGap := range []int{0, 20, 32} {
+		fmt.Fprintf(&sb, "maxGap %d\n", maxGap)
+		for _, s := range MergeAdjacentSegments(segs, maxGap, 0.55) {
+			fmt.Fprintf(&sb, "  q[%d,%d] r[%d,%d] mapq=%d %s id=%.4f\n", s.QueryStart, s.QueryEnd, s.RefStart, s.RefEnd, s.MapQ, s.Source, s.Identity)
+		}
+	}
+	golden.AssertString(t, sb.String())
+}
@@ -0,0 +1,24 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// BEDInterval is one BED record. Start is 0-based and End exclusive, as in the BED format.
+type BEDInterval struct {
+	Start int
+	End   int
+	Name  string
+	Score int
+}
+
+// WriteBED writes intervals on a single chromosome as BED6 lines (strand is always ".").
+func WriteBED(w io.Writer, chrom string, intervals []BEDInterval) error {
+	bw := bufio.NewWriter(w)
+	for _, iv := range intervals {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%s\t%d\t.\n", chrom, iv.Start, iv.End, iv.Name, iv.Score)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,89 @@
+package output
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"bytes"
+	"testing"
+)
+
+// truthPair returns the mixed scenario with its true segments, tagged with varied provenance.
+func truthPair() (Pair, []common.Segment) {
+	res := simulate.Scenarios[len(simulate.Scenarios)-1].Generate(1)
+	segs := res.Segments()
+	sources := []common.SegmentSource{common.SourceAnchor, common.SourceRegionRescan, common.SourceSampledFallback, common.SourceModuloFallback}
+	for i := range segs {
+		segs[i].Source = sources[i%len(sources)]
+		segs[i].MapQ = 60 - 10*i
+		segs[i].Identity = 1.0 - 0.01*float64(i)
+	}
+	return Pair{QueryName: "sim_query", Query: res.Query, RefName: "sim_ref", Ref: res.Ref}, segs
+}
+
+func TestFormatTuples(t *testing.T) {
+	_, segs := truthPair()
+	golden.AssertString(t, FormatTuples(segs)+"\n"+FormatTuples(nil)+"\n")
+}
+
+func TestWritePAF(t *testing.T) {
+	pair, segs := truthPair()
+	p := config.Default()
+	var buf bytes.Buffer
+	if err := WritePAF(&buf, pair, segs, AlignSegments(pair, &p, scoring.FromParams(&p), segs)); err != nil {
+		t.Fatal(err)
+	}
+	golden.Assert(t, buf.Bytes())
+}
+
+func TestWriteSAM(t *testing.T) {
+	pair, segs := truthPair()
+	p := config.Default()
+	var buf bytes.Buffer
+	if err := WriteSAMHeader(&buf, pair.RefName, len(pair.Ref)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSAMRecords(&buf, pair, segs, AlignSegments(pair, &p, scoring.FromParams(&p), segs)); err != nil {
+		t.Fatal(err)
+	}
+	golden.Assert(t, buf.Bytes())
+}
+
+// TestWriteSAMUnmapped checks that a query without segments is written as one unmapped record.
+func TestWriteSAMUnmapped(t *testing.T) {
+	pair := Pair{QueryName: "lost", Query: "ACGTTGCA", RefName: "ref", Ref: "GGGGCCCC"}
+	var buf bytes.Buffer
+	if err := WriteSAMHeader(&buf, pair.RefName, len(pair.Ref)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSAMRecords(&buf, pair, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	golden.Assert(t, buf.Bytes())
+}
+
+// TestSAMPrimaryRecord checks that the highest MAPQ, then the highest score, then the first
+// segment is chosen as the primary record.
+func TestSAMPrimaryRecord(t *testing.T) {
+	segs := []common.Segment{{MapQ: 10}, {MapQ: 60}, {MapQ: 60}}
+	alns := []pairwise.Alignment{{Score: 90}, {Score: 50}, {Score: 80}}
+	if got := primaryRecord(segs, alns); got != 2 {
+		t.Errorf("primary record %d, want 2", got)
+	}
+	alns[2].Score = 50
+	if got := primaryRecord(segs, alns); got != 1 {
+		t.Errorf("tied records: primary record %d, want 1", got)
+	}
+}
+
+func TestWriteBED(t *testing.T) {
+	var buf bytes.Buffer
+	intervals := []BEDInterval{{Start: 0, End: 10, Name: "uncovered", Score: 0}, {Start: 40, End: 95, Name: "depth=3", Score: 3}}
+	if err := WriteBED(&buf, "chr1", intervals); err != nil {
+		t.Fatal(err)
+	}
+	golden.Assert(t, buf.Bytes())
+}
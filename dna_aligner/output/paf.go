@@ -0,0 +1,57 @@
+package output
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Pair holds the two sequences an alignment was computed for, with the names used in output records.
+type Pair struct {
+	QueryName string
+	Query     string
+	RefName   string
+	Ref       string
+}
+
+// AlignSegments computes the base-level alignment of every segment with p and scheme, in order.
+func AlignSegments(pair Pair, p *config.Params, scheme *scoring.Scheme, segments []common.Segment) []pairwise.Alignment {
+	alignments := make([]pairwise.Alignment, len(segments))
+	for i, seg := range segments {
+		alignments[i] = pairwise.AlignSegment(pair.Query, pair.Ref, p, scheme, seg)
+	}
+	return alignments
+}
+
+// WritePAF writes one PAF line per segment. alignments must correspond to segments, as in
+// aligner.Result or as computed by AlignSegments.
+func WritePAF(w io.Writer, pair Pair, segments []common.Segment, alignments []pairwise.Alignment) error {
+	bw := bufio.NewWriter(w)
+	for i, seg := range segments {
+		aln := alignments[i]
+		strand := "+"
+		if aln.Reverse {
+			strand = "-"
+		}
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\ttp:A:P\tNM:i:%d\tAS:i:%d\t%s\tcg:Z:%s\n",
+			pair.QueryName, len(pair.Query), seg.QueryStart, seg.QueryEnd+1, strand,
+			pair.RefName, len(pair.Ref), seg.RefStart, seg.RefEnd+1,
+			aln.Matches, aln.Columns(), seg.MapQ,
+			aln.Mismatches+aln.Insertions+aln.Deletions, aln.Score, provenanceTags(seg), aln.CIGAR())
+	}
+	return bw.Flush()
+}
+
+// provenanceTags returns the st (source stage), id (identity measured at that stage), bs (bit score)
+// and ev (E-value) tags of a segment, and the ci (tandem copy index) tag of a tandem copy.
+func provenanceTags(seg common.Segment) string {
+	tags := fmt.Sprintf("st:Z:%s\tid:f:%.4f\tbs:f:%.1f\tev:f:%.3g", seg.Source, seg.Identity, seg.BitScore, seg.EValue)
+	if seg.CopyIndex > 0 {
+		tags += fmt.Sprintf("\tci:i:%d", seg.CopyIndex)
+	}
+	return tags
+}
@@ -0,0 +1,90 @@
+package output
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// SAM flag bits used by the writer.
+const (
+	samFlagUnmapped      = 4
+	samFlagReverse       = 16
+	samFlagSupplementary = 2048
+)
+
+// WriteSAMHeader writes the @HD, @SQ and @PG header lines for a single reference sequence,
+// followed by one @CO line per comment.
+func WriteSAMHeader(w io.Writer, refName string, refLen int, comments ...string) error {
+	_, err := fmt.Fprintf(w, "@HD\tVN:1.6\tSO:unsorted\n@SQ\tSN:%s\tLN:%d\n@PG\tID:dna_aligner\tPN:dna_aligner\n", refName, refLen)
+	for _, c := range comments {
+		if err != nil {
+			break
+		}
+		_, err = fmt.Fprintf(w, "@CO\t%s\n", c)
+	}
+	return err
+}
+
+// WriteSAMRecords writes one SAM record per segment, in order. The segment with the highest MAPQ
+// (then the highest alignment score, then the first) is the primary alignment of the query and
+// the others are supplementary. Unaligned query bases are soft-clipped. A query without segments
+// gets a single unmapped record, so that it is not lost downstream.
+func WriteSAMRecords(w io.Writer, pair Pair, segments []common.Segment, alignments []pairwise.Alignment) error {
+	bw := bufio.NewWriter(w)
+	if len(segments) == 0 {
+		fmt.Fprintf(bw, "%s\t%d\t*\t0\t0\t*\t*\t0\t0\t%s\t*\n", pair.QueryName, samFlagUnmapped, pair.Query)
+		return bw.Flush()
+	}
+	queryLen := len(pair.Query)
+	var revQuery string // Computed lazily for reverse-strand records
+	primary := primaryRecord(segments, alignments)
+
+	for i, seg := range segments {
+		aln := alignments[i]
+		flag := 0
+		seq := pair.Query
+		leadClip, trailClip := seg.QueryStart, queryLen-1-seg.QueryEnd
+		if aln.Reverse {
+			flag |= samFlagReverse
+			if revQuery == "" {
+				revQuery = sequence.ReverseComplement(pair.Query)
+			}
+			seq = revQuery
+			leadClip, trailClip = trailClip, leadClip
+		}
+		if i != primary {
+			flag |= samFlagSupplementary
+		}
+
+		cigar := aln.CIGAR()
+		if leadClip > 0 {
+			cigar = strconv.Itoa(leadClip) + "S" + cigar
+		}
+		if trailClip > 0 {
+			cigar += strconv.Itoa(trailClip) + "S"
+		}
+
+		fmt.Fprintf(bw, "%s\t%d\t%s\t%d\t%d\t%s\t*\t0\t0\t%s\t*\tNM:i:%d\tAS:i:%d\t%s\n",
+			pair.QueryName, flag, pair.RefName, seg.RefStart+1, seg.MapQ, cigar, seq,
+			aln.Mismatches+aln.Insertions+aln.Deletions, aln.Score, provenanceTags(seg))
+	}
+	return bw.Flush()
+}
+
+// primaryRecord returns the index of the segment reported as the primary alignment: the highest
+// MAPQ, then the highest alignment score, then the first.
+func primaryRecord(segments []common.Segment, alignments []pairwise.Alignment) int {
+	best := 0
+	for i := 1; i < len(segments); i++ {
+		if segments[i].MapQ > segments[best].MapQ ||
+			segments[i].MapQ == segments[best].MapQ && alignments[i].Score > alignments[best].Score {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,18 @@
+package output
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"fmt"
+	"strings"
+)
+
+// FormatTuples formats segments as a Python-style list of (query_start, query_end, ref_start, ref_end)
+// tuples with half-open end coordinates, the format of the bundled result files.
+func FormatTuples(segments []common.Segment) string {
+	var parts []string
+	for _, seg := range segments {
+		parts = append(parts, fmt.Sprintf("(%d, %d, %d, %d)", seg.QueryStart, seg.QueryEnd+1, seg.RefStart, seg.RefEnd+1))
+	}
+	// Matches Python's str() output for a list of tuples: [(v1,v2,v3,v4), (v5,v6,v7,v8)]
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,332 @@
+package pairwise
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Operation kinds, using the extended CIGAR alphabet.
+const (
+	OpMatch     = '=' // query and reference bases are identical
+	OpMismatch  = 'X' // substitution
+	OpInsertion = 'I' // bases present in the query only
+	OpDeletion  = 'D' // bases present in the reference only
+)
+
+// Op is a run of identical alignment operations.
+type Op struct {
+	Kind byte
+	Len  int
+}
+
+// Alignment is a base-level alignment of a query span against a reference span.
+// Ops always walk the reference forward. For reverse alignments they describe the
+// reverse complement of the query span, as in SAM.
+type Alignment struct {
+	Ops        []Op
+	Reverse    bool
+	Score      int
+	Matches    int
+	Mismatches int
+	Insertions int // query bases not present in the reference
+	Deletions  int // reference bases not present in the query
+}
+
+// CIGAR returns the alignment operations as an extended CIGAR string.
+func (a Alignment) CIGAR() string {
+	var sb strings.Builder
+	for _, op := range a.Ops {
+		sb.WriteString(strconv.Itoa(op.Len))
+		sb.WriteByte(op.Kind)
+	}
+	return sb.String()
+}
+
+// Columns returns the number of alignment columns (matches, mismatches and gap positions).
+func (a Alignment) Columns() int {
+	return a.Matches + a.Mismatches + a.Insertions + a.Deletions
+}
+
+// Identity returns the fraction of alignment columns that are matches.
+func (a Alignment) Identity() float64 {
+	cols := a.Columns()
+	if cols == 0 {
+		return 0.0
+	}
+	return float64(a.Matches) / float64(cols)
+}
+
+// RefOffset returns the number of reference bases walked by the alignment up to its first n
+// query bases (of the reverse-complemented span for reverse alignments). Deletions that follow
+// the n-th query base are not counted.
+func (a Alignment) RefOffset(n int) int {
+	refBases := 0
+	for _, op := range a.Ops {
+		if n <= 0 {
+			break
+		}
+		switch op.Kind {
+		case OpDeletion:
+			refBases += op.Len
+		case OpInsertion:
+			n -= op.Len
+		default:
+			step := min(op.Len, n)
+			refBases += step
+			n -= step
+		}
+	}
+	return refBases
+}
+
+// AlignSegment aligns the query and reference spans of seg at base level.
+// Both orientations are tried and the higher-scoring one is returned.
+func AlignSegment(query, ref string, p *config.Params, scheme *scoring.Scheme, seg common.Segment) Alignment {
+	qSpan := query[seg.QueryStart : seg.QueryEnd+1]
+	rSpan := ref[seg.RefStart : seg.RefEnd+1]
+
+	fwd := Align(qSpan, rSpan, p, scheme)
+	rev := Align(sequence.ReverseComplement(qSpan), rSpan, p, scheme)
+	rev.Reverse = true
+	if rev.Score > fwd.Score {
+		return rev
+	}
+	return fwd
+}
+
+// Align computes a banded global alignment of query against ref with affine gap costs.
+// The band of p.PairwiseBandWidth follows the main diagonal and is widened by the length
+// difference of the inputs. Above p.PairwiseMaxCells DP cells the bases are paired along the
+// diagonal instead.
+func Align(query, ref string, p *config.Params, scheme *scoring.Scheme) Alignment {
+	n, m := len(query), len(ref)
+	if n == 0 || m == 0 {
+		return gapOnlyAlignment(query, ref, scheme)
+	}
+
+	bandWidth := p.PairwiseBandWidth
+	kMin := min(0, m-n) - bandWidth // Lowest diagonal (j - i) inside the band
+	kMax := max(0, m-n) + bandWidth // Highest diagonal inside the band
+	width := kMax - kMin + 1
+	if (n+1)*width > p.PairwiseMaxCells {
+		return diagonalAlignment(query, ref, scheme)
+	}
+
+	gapOpen, gapExtend := scheme.GapOpen, scheme.GapExtend
+	negInf := math.MinInt32 / 2
+
+	// Rows are indexed by diagonal offset: cell (i, j) lives at column j - i - kMin.
+	prevH, prevE, prevF := make([]int, width), make([]int, width), make([]int, width)
+	curH, curE, curF := make([]int, width), make([]int, width), make([]int, width)
+	trace := make([]byte, (n+1)*width)
+
+	for idx := 0; idx < width; idx++ {
+		prevH[idx], prevE[idx], prevF[idx] = negInf, negInf, negInf
+		j := idx + kMin
+		if j == 0 {
+			prevH[idx] = 0
+		} else if j > 0 && j <= m {
+			prevH[idx] = -(gapOpen + j*gapExtend)
+			prevE[idx] = prevH[idx]
+		}
+	}
+
+	for i := 1; i <= n; i++ {
+		rowTrace := trace[i*width : (i+1)*width]
+		for idx := 0; idx < width; idx++ {
+			curH[idx], curE[idx], curF[idx] = negInf, negInf, negInf
+			j := i + idx + kMin
+			if j < 0 || j > m {
+				continue
+			}
+			if j == 0 {
+				curH[idx] = -(gapOpen + i*gapExtend)
+				curF[idx] = curH[idx]
+				continue
+			}
+
+			var tb byte
+			// Deletion: move along the reference within this row.
+			if idx > 0 {
+				open, ext := curH[idx-1]-gapOpen-gapExtend, curE[idx-1]-gapExtend
+				if ext > open {
+					curE[idx] = ext
+					tb |= traceEExtend
+				} else {
+					curE[idx] = open
+				}
+			}
+			// Insertion: move along the query from the previous row.
+			if idx+1 < width {
+				open, ext := prevH[idx+1]-gapOpen-gapExtend, prevF[idx+1]-gapExtend
+				if ext > open {
+					curF[idx] = ext
+					tb |= traceFExtend
+				} else {
+					curF[idx] = open
+				}
+			}
+
+			best := prevH[idx] + scheme.Score(query[i-1], ref[j-1])
+			src := traceFromDiag
+			if curE[idx] > best {
+				best, src = curE[idx], traceFromE
+			}
+			if curF[idx] > best {
+				best, src = curF[idx], traceFromF
+			}
+			curH[idx] = best
+			rowTrace[idx] = tb | src
+		}
+		prevH, curH = curH, prevH
+		prevE, curE = curE, prevE
+		prevF, curF = curF, prevF
+	}
+
+	aln := Alignment{Ops: traceback(query, ref, trace, width, kMin)}
+	aln.countOps(query, ref, scheme)
+	return aln
+}
+
+// Trace flags: the low two bits select the source of H, the others record gap extension.
+const (
+	traceFromDiag byte = 0
+	traceFromE    byte = 1
+	traceFromF    byte = 2
+	traceEExtend  byte = 4
+	traceFExtend  byte = 8
+)
+
+// traceback walks the trace matrix from (n, m) back to the origin and returns run-length encoded ops.
+func traceback(query, ref string, trace []byte, width, kMin int) []Op {
+	i, j := len(query), len(ref)
+	state := traceFromDiag
+	var kinds []byte // Reversed, one entry per column
+
+	for i > 0 && j > 0 {
+		tb := trace[i*width+j-i-kMin]
+		switch state {
+		case traceFromDiag:
+			switch tb & 3 {
+			case traceFromE:
+				state = traceFromE
+				continue
+			case traceFromF:
+				state = traceFromF
+				continue
+			}
+			if scoring.IsMatch(query[i-1], ref[j-1]) {
+				kinds = append(kinds, OpMatch)
+			} else {
+				kinds = append(kinds, OpMismatch)
+			}
+			i--
+			j--
+		case traceFromE:
+			kinds = append(kinds, OpDeletion)
+			if tb&traceEExtend == 0 {
+				state = traceFromDiag
+			}
+			j--
+		case traceFromF:
+			kinds = append(kinds, OpInsertion)
+			if tb&traceFExtend == 0 {
+				state = traceFromDiag
+			}
+			i--
+		}
+	}
+	for ; j > 0; j-- {
+		kinds = append(kinds, OpDeletion)
+	}
+	for ; i > 0; i-- {
+		kinds = append(kinds, OpInsertion)
+	}
+
+	var ops []Op
+	for k := len(kinds) - 1; k >= 0; k-- {
+		if len(ops) > 0 && ops[len(ops)-1].Kind == kinds[k] {
+			ops[len(ops)-1].Len++
+		} else {
+			ops = append(ops, Op{Kind: kinds[k], Len: 1})
+		}
+	}
+	return ops
+}
+
+// diagonalAlignment is used when the band would be too large: bases are paired along
+// the diagonal and the length difference is reported as a trailing gap.
+func diagonalAlignment(query, ref string, scheme *scoring.Scheme) Alignment {
+	var aln Alignment
+	shared := min(len(query), len(ref))
+	for k := 0; k < shared; k++ {
+		kind := byte(OpMatch)
+		if !scoring.IsMatch(query[k], ref[k]) {
+			kind = OpMismatch
+		}
+		aln.appendOp(kind, 1)
+	}
+	if len(query) > shared {
+		aln.appendOp(OpInsertion, len(query)-shared)
+	} else if len(ref) > shared {
+		aln.appendOp(OpDeletion, len(ref)-shared)
+	}
+	aln.countOps(query, ref, scheme)
+	return aln
+}
+
+// gapOnlyAlignment aligns an empty sequence against a non-empty one.
+func gapOnlyAlignment(query, ref string, scheme *scoring.Scheme) Alignment {
+	var aln Alignment
+	if len(query) > 0 {
+		aln.appendOp(OpInsertion, len(query))
+	}
+	if len(ref) > 0 {
+		aln.appendOp(OpDeletion, len(ref))
+	}
+	aln.countOps(query, ref, scheme)
+	return aln
+}
+
+func (a *Alignment) appendOp(kind byte, n int) {
+	if len(a.Ops) > 0 && a.Ops[len(a.Ops)-1].Kind == kind {
+		a.Ops[len(a.Ops)-1].Len += n
+		return
+	}
+	a.Ops = append(a.Ops, Op{Kind: kind, Len: n})
+}
+
+// countOps fills the per-operation base counts and the score from Ops, which align query against ref.
+func (a *Alignment) countOps(query, ref string, scheme *scoring.Scheme) {
+	a.Matches, a.Mismatches, a.Insertions, a.Deletions = 0, 0, 0, 0
+	a.Score = 0
+	i, j := 0, 0
+	for _, op := range a.Ops {
+		switch op.Kind {
+		case OpMatch, OpMismatch:
+			for k := 0; k < op.Len; k++ {
+				a.Score += scheme.Score(query[i+k], ref[j+k])
+			}
+			if op.Kind == OpMatch {
+				a.Matches += op.Len
+			} else {
+				a.Mismatches += op.Len
+			}
+			i += op.Len
+			j += op.Len
+		case OpInsertion:
+			a.Insertions += op.Len
+			a.Score -= scheme.Gap(op.Len)
+			i += op.Len
+		case OpDeletion:
+			a.Deletions += op.Len
+			a.Score -= scheme.Gap(op.Len)
+			j += op.Len
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package pairwise_test
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func describe(a pairwise.Alignment) string {
+	return fmt.Sprintf("cigar=%s reverse=%v score=%d =%d X%d I%d D%d id=%.4f",
+		a.CIGAR(), a.Reverse, a.Score, a.Matches, a.Mismatches, a.Insertions, a.Deletions, a.Identity())
+}
+
+func TestAlign(t *testing.T) {
+	cases := []struct{ name, query, ref string }{
+		{"identical", "ACGTACGTAC", "ACGTACGTAC"},
+		{"mismatch", "ACGTTCGTAC", "ACGTACGTAC"},
+		{"insertion", "ACGTAGGGCGTAC", "ACGTACGTAC"},
+		{"deletion", "ACGTAC", "ACGTACGTAC"},
+		{"empty query", "", "ACGT"},
+		{"empty ref", "ACGT", ""},
+	}
+	p := config.Default()
+	scheme := scoring.FromParams(&p)
+	var sb strings.Builder
+	for _, c := range cases {
+		fmt.Fprintf(&sb, "%s: %s\n", c.name, describe(pairwise.Align(c.query, c.ref, &p, scheme)))
+	}
+	golden.AssertString(t, sb.String())
+}
+
+// TestAlignSegmentScenarios aligns every true block of every scenario and checks the strand,
+// that the operations consume exactly the block's bases and that the identity stays close to the
+// simulated divergence on blocks of 100 bases or more.
+func TestAlignSegmentScenarios(t *testing.T) {
+	p := config.Default()
+	scheme := scoring.FromParams(&p)
+	for _, sc := range simulate.Scenarios {
+		res := sc.Generate(1)
+		for _, b := range res.Blocks {
+			if b.QueryEnd <= b.QueryStart || b.RefEnd <= b.RefStart {
+				continue
+			}
+			seg := common.Segment{QueryStart: b.QueryStart, QueryEnd: b.QueryEnd - 1, RefStart: b.RefStart, RefEnd: b.RefEnd - 1}
+			aln := pairwise.AlignSegment(res.Query, res.Ref, &p, scheme, seg)
+			if aln.Reverse != b.Reverse {
+				t.Errorf("%s: block %+v aligned with reverse=%v", sc.Name, b, aln.Reverse)
+			}
+			if aln.Matches+aln.Mismatches+aln.Insertions != b.QueryEnd-b.QueryStart || aln.Matches+aln.Mismatches+aln.Deletions != b.RefEnd-b.RefStart {
+				t.Errorf("%s: block %+v: %s does not span the block", sc.Name, b, describe(aln))
+			}
+			if b.QueryEnd-b.QueryStart >= 100 && aln.Identity() < 0.95 {
+				t.Errorf("%s: block %+v: %s", sc.Name, b, describe(aln))
+			}
+		}
+	}
+}
+
+func TestAlignSegmentReverse(t *testing.T) {
+	ref := "TTTTACGGATCCAGTTGACCATGTTTT"
+	query := sequence.ReverseComplement(ref[4:23])
+	p := config.Default()
+	aln := pairwise.AlignSegment(query, ref, &p, scoring.FromParams(&p), common.Segment{QueryStart: 0, QueryEnd: len(query) - 1, RefStart: 4, RefEnd: 22})
+	if !aln.Reverse || aln.Mismatches != 0 || aln.Matches != len(query) {
+		t.Errorf("inverted copy: got %s", describe(aln))
+	}
+}
+
+func TestRefOffset(t *testing.T) {
+	aln := pairwise.Alignment{Ops: []pairwise.Op{{Kind: pairwise.OpMatch, Len: 3}, {Kind: pairwise.OpDeletion, Len: 2}, {Kind: pairwise.OpMismatch, Len: 1}, {Kind: pairwise.OpInsertion, Len: 2}, {Kind: pairwise.OpMatch, Len: 4}}}
+	for _, c := range []struct{ n, want int }{{0, 0}, {2, 2}, {3, 3}, {4, 6}, {6, 6}, {7, 7}, {10, 10}} {
+		if got := aln.RefOffset(c.n); got != c.want {
+			t.Errorf("RefOffset(%d) = %d, want %d", c.n, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package parallel
+
+import "sync"
+
+// ForEach calls fn(i) for every i in [0, n) on up to threads worker goroutines and waits for all calls.
+// With threads <= 1 the calls run sequentially, in order, on the calling goroutine.
+// fn must only write to per-index state (e.g. element i of a pre-sized slice) so that results
+// do not depend on scheduling.
+func ForEach(n, threads int, fn func(i int)) {
+	if threads <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	workers := min(threads, n)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
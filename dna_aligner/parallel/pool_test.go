@@ -0,0 +1,33 @@
+package parallel
+
+import (
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestForEachVisitsEveryIndexOnce(t *testing.T) {
+	var want string
+	for _, threads := range []int{0, 1, 3, 8, 64} {
+		results := make([]int, 20)
+		visits := make([]int, 20)
+		ForEach(len(results), threads, func(i int) {
+			results[i] = i * i
+			visits[i]++
+		})
+		for i, v := range visits {
+			if v != 1 {
+				t.Fatalf("threads=%d: index %d visited %d times", threads, i, v)
+			}
+		}
+		got := strings.Trim(fmt.Sprint(results), "[]") + "\n"
+		if want == "" {
+			want = got
+			golden.AssertString(t, got)
+		} else if got != want {
+			t.Errorf("threads=%d: results %s differ from sequential %s", threads, got, want)
+		}
+	}
+	ForEach(0, 4, func(int) { t.Error("called for an empty range") })
+}
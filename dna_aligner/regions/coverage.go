@@ -3,17 +3,15 @@ package regions
 import (
 	"DNA-Sequence-Alignments/dna_aligner/common"
 	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/matching"
+	"DNA-Sequence-Alignments/dna_aligner/parallel"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
 	"fmt"
 	"math"
-	"math/rand"
 	"sort"
-	"time"
+	"strings"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 // ResolveOverlaps resolves overlapping segments in query coordinates by keeping the longer segment.
 // Input segments are sorted by QueryStart. If not, this function will sort them.
 func ResolveOverlaps(segments []common.Segment) []common.Segment {
@@ -23,7 +21,7 @@ func ResolveOverlaps(segments []common.Segment) []common.Segment {
 
 	sortedSegs := make([]common.Segment, len(segments))
 	copy(sortedSegs, segments)
-	sort.Slice(sortedSegs, func(i, j int) bool {
+	sort.SliceStable(sortedSegs, func(i, j int) bool {
 		if sortedSegs[i].QueryStart != sortedSegs[j].QueryStart {
 			return sortedSegs[i].QueryStart < sortedSegs[j].QueryStart
 		}
@@ -33,7 +31,10 @@ func ResolveOverlaps(segments []common.Segment) []common.Segment {
 		if lenI != lenJ {
 			return lenI > lenJ
 		} // Longer first
-		return sortedSegs[i].RefStart < sortedSegs[j].RefStart
+		if sortedSegs[i].RefStart != sortedSegs[j].RefStart {
+			return sortedSegs[i].RefStart < sortedSegs[j].RefStart
+		}
+		return sortedSegs[i].RefEnd < sortedSegs[j].RefEnd
 	})
 
 	var result []common.Segment
@@ -70,8 +71,25 @@ func isSegmentOriginal(segment common.Segment, originalSegments []common.Segment
 	return false
 }
 
+// CoverageOptions controls how EnsureCompleteCoverage fills uncovered query regions.
+type CoverageOptions struct {
+	// Repeats (may be nil) is used to estimate the mapping quality of segments found in uncovered regions.
+	Repeats *matching.RepeatIndex
+	// NoFallback leaves regions without any match unaligned instead of inventing
+	// sampled or modulo placements for them.
+	NoFallback bool
+	// Threads is the number of worker goroutines. They are shared out between the uncovered regions,
+	// and a large region spends its share on its chunks.
+	Threads int
+	// Params are the aligner parameters; nil selects config.Default().
+	Params *config.Params
+	// Scheme scores the anchors found in uncovered regions; nil builds it from Params.
+	Scheme *scoring.Scheme
+}
+
 // EnsureCompleteCoverage ensures the entire query is covered by finding matches for uncovered regions.
-func EnsureCompleteCoverage(query, ref string, initialSegments []common.Segment) []common.Segment {
+// Unless opts.NoFallback is set, regions without matches receive fallback segments tagged with their source.
+func EnsureCompleteCoverage(query, ref string, initialSegments []common.Segment, opts CoverageOptions) []common.Segment {
 	queryLen := len(query)
 	refLen := len(ref)
 	if queryLen == 0 {
@@ -82,133 +100,44 @@ func EnsureCompleteCoverage(query, ref string, initialSegments []common.Segment)
 	// Let's make a mutable copy and sort it.
 	currentCoverageSegments := make([]common.Segment, len(initialSegments))
 	copy(currentCoverageSegments, initialSegments)
-	sort.Slice(currentCoverageSegments, func(i, j int) bool {
-		return currentCoverageSegments[i].QueryStart < currentCoverageSegments[j].QueryStart
+	sort.SliceStable(currentCoverageSegments, func(i, j int) bool {
+		return common.SegmentLess(currentCoverageSegments[i], currentCoverageSegments[j])
 	})
 
 	uncovered := FindUncoveredRegions(queryLen, currentCoverageSegments)
 
 	if len(uncovered) == 0 {
-		fmt.Println("Query already has complete coverage based on initial segments.")
+		common.Logf("Query already has complete coverage based on initial segments.\n")
 		return currentCoverageSegments // Already sorted and presumably non-overlapping if initialSegments were clean
 	}
-	fmt.Printf("Found %d uncovered regions in query\n", len(uncovered))
+	common.Logf("Found %d uncovered regions in query\n", len(uncovered))
+	if opts.Params == nil {
+		p := config.Default()
+		opts.Params = &p
+	}
+	if opts.Scheme == nil {
+		opts.Scheme = scoring.FromParams(opts.Params)
+	}
 
+	// Regions are searched independently; each keeps its own log so messages come out in region order.
+	// Every region gets an equal share of the threads for its chunks, so at most opts.Threads run at once.
+	foundPerRegion := make([][]common.Segment, len(uncovered))
+	logPerRegion := make([]strings.Builder, len(uncovered))
+	chunkThreads := max(1, opts.Threads/len(uncovered))
+	parallel.ForEach(len(uncovered), opts.Threads, func(i int) {
+		logf := func(format string, args ...any) { fmt.Fprintf(&logPerRegion[i], format, args...) }
+		foundPerRegion[i] = processUncoveredRegion(query, ref, uncovered[i][0], uncovered[i][1], i, len(uncovered), chunkThreads, opts, logf)
+	})
 	newlyFoundSegments := []common.Segment{}
-
-	for i, regionCoords := range uncovered {
-		qStart, qEnd := regionCoords[0], regionCoords[1]
-		regionActualLen := (qEnd - qStart) + 1
-		fmt.Printf("Processing uncovered region %d/%d: query pos %d-%d (length: %d)\n",
-			i+1, len(uncovered), qStart, qEnd, regionActualLen)
-
-		if regionActualLen < config.VerySmallRegionThreshold {
-			fmt.Printf("  Skipping very small region (length: %d)\n", regionActualLen)
-			continue
-		}
-
-		queryRegionStr := query[qStart : qEnd+1]
-		var regionMatches []common.AnchorMatch // Relative coordinates
-
-		if regionActualLen > 1000 { // Python's threshold for "large region" specific handling
-			fmt.Println("  Large region detected, using divide-and-conquer approach")
-			regionMatches = FindMatchesInLargeRegion(queryRegionStr, ref, 500, 0, 0) // 500 is from python's call
-		} else {
-			regionMatches = FindMatchesInRegion(queryRegionStr, ref, 0, 0)
-		}
-
-		if len(regionMatches) > 0 {
-			fmt.Printf("  Found %d potential matches for this region\n", len(regionMatches))
-			sort.Slice(regionMatches, func(i, j int) bool { // Sort by score
-				return regionMatches[i].Score > regionMatches[j].Score
-			})
-
-			// Add non-overlapping (with current new finds for this region) matches
-			tempAddedForThisRegion := []common.Segment{}
-			for _, match := range regionMatches {
-				absQStart := match.QueryStart + qStart
-				absQEnd := match.QueryEnd + qStart
-
-				// Check overlap with segments already added *for this specific uncovered region processing iteration*
-				isOverlappingTemp := false
-				for _, tempSeg := range tempAddedForThisRegion {
-					if math.Max(float64(tempSeg.QueryStart), float64(absQStart)) <= math.Min(float64(tempSeg.QueryEnd), float64(absQEnd)) {
-						isOverlappingTemp = true
-						break
-					}
-				}
-				if !isOverlappingTemp {
-					segToAdd := common.Segment{
-						QueryStart: absQStart, QueryEnd: absQEnd,
-						RefStart: match.RefStart, RefEnd: match.RefEnd,
-					}
-					tempAddedForThisRegion = append(tempAddedForThisRegion, segToAdd)
-					newlyFoundSegments = append(newlyFoundSegments, segToAdd)
-				}
-			}
-		} else { // No matches found for region, Python's fallback logic
-			fmt.Printf("  No matches found for region. Creating fallback segments.\n")
-			if regionActualLen > config.MaxSegmentSize {
-				chunkStep := config.SmallSegmentLength
-				for chunkOffset := 0; chunkOffset < regionActualLen; chunkOffset += chunkStep {
-					curChunkStartInRegion := chunkOffset
-					curChunkEndInRegion := int(math.Min(float64(chunkOffset+chunkStep), float64(regionActualLen)))
-					if curChunkEndInRegion <= curChunkStartInRegion {
-						continue
-					}
-
-					absQChunkStart := qStart + curChunkStartInRegion
-					absQChunkEnd := qStart + curChunkEndInRegion - 1
-					chunkStr := query[absQChunkStart : absQChunkEnd+1]
-					chunkActualLen := len(chunkStr)
-
-					bestRStart, bestScore := 0, -1.0
-					sampleStep := int(math.Max(1, float64(refLen/config.SamplePositionsCount)))
-					if sampleStep == 0 {
-						sampleStep = 1
-					}
-
-					for rPos := 0; rPos <= refLen-chunkActualLen; rPos += sampleStep {
-						refChunkStr := ref[rPos : rPos+chunkActualLen]
-						matchesCount := 0
-						for k := 0; k < chunkActualLen; k++ {
-							if chunkStr[k] == refChunkStr[k] {
-								matchesCount++
-							}
-						}
-						score := float64(matchesCount) / float64(chunkActualLen)
-						if score > bestScore {
-							bestScore, bestRStart = score, rPos
-						}
-					}
-					newlyFoundSegments = append(newlyFoundSegments, common.Segment{
-						QueryStart: absQChunkStart, QueryEnd: absQChunkEnd,
-						RefStart: bestRStart, RefEnd: bestRStart + chunkActualLen - 1,
-					})
-				}
-			} else { // Smaller region, no matches, add one fallback segment
-				rMapStart := qStart % refLen
-				rMapEnd := rMapStart + regionActualLen - 1
-				if rMapEnd >= refLen { // Ensure it fits
-					rMapEnd = refLen - 1
-					if rMapStart > rMapEnd && regionActualLen <= refLen {
-						rMapStart = 0
-					} // Adjust start if possible
-					if regionActualLen > refLen {
-						rMapStart = 0
-					} // query region longer than ref
-				}
-				newlyFoundSegments = append(newlyFoundSegments, common.Segment{
-					QueryStart: qStart, QueryEnd: qEnd, RefStart: rMapStart, RefEnd: rMapEnd,
-				})
-			}
-		}
+	for i := range uncovered {
+		common.Logf("%s", logPerRegion[i].String())
+		newlyFoundSegments = append(newlyFoundSegments, foundPerRegion[i]...)
 	}
 
 	// Combine initial segments with newly found ones for uncovered regions
 	allSegments := append(currentCoverageSegments, newlyFoundSegments...)
-	sort.Slice(allSegments, func(i, j int) bool { // Sort all before resolving overlaps
-		return allSegments[i].QueryStart < allSegments[j].QueryStart
+	sort.SliceStable(allSegments, func(i, j int) bool { // Sort all before resolving overlaps
+		return common.SegmentLess(allSegments[i], allSegments[j])
 	})
 
 	// Python's complex non-overlapping resolution:
@@ -241,8 +170,10 @@ func EnsureCompleteCoverage(query, ref string, initialSegments []common.Segment)
 
 	// Final check for uncovered regions and fill them if any (Python's final fallback)
 	finalUncovered := FindUncoveredRegions(queryLen, resolvedWithPreference)
-	if len(finalUncovered) > 0 {
-		fmt.Printf("Warning: %d regions still uncovered. Adding final fallback segments.\n", len(finalUncovered))
+	if len(finalUncovered) > 0 && opts.NoFallback {
+		common.Logf("%d regions left unaligned (fallback disabled).\n", len(finalUncovered))
+	} else if len(finalUncovered) > 0 {
+		common.Logf("Warning: %d regions still uncovered. Adding final fallback segments.\n", len(finalUncovered))
 		for _, reg := range finalUncovered {
 			s, e := reg[0], reg[1]
 			rLen := (e - s) + 1
@@ -256,7 +187,10 @@ func EnsureCompleteCoverage(query, ref string, initialSegments []common.Segment)
 					rS = 0
 				}
 			}
-			resolvedWithPreference = append(resolvedWithPreference, common.Segment{QueryStart: s, QueryEnd: e, RefStart: rS, RefEnd: rE})
+			resolvedWithPreference = append(resolvedWithPreference, common.Segment{
+				QueryStart: s, QueryEnd: e, RefStart: rS, RefEnd: rE,
+				Source: common.SourceModuloFallback, Identity: ungappedIdentity(query[s:e+1], ref[rS:rE+1]),
+			})
 		}
 		// Re-sort and resolve all overlaps finally
 		return ResolveOverlaps(resolvedWithPreference)
@@ -264,3 +198,199 @@ func EnsureCompleteCoverage(query, ref string, initialSegments []common.Segment)
 
 	return resolvedWithPreference
 }
+
+// processUncoveredRegion searches the uncovered query region [qStart, qEnd] (inclusive) for matches and
+// returns the segments placed in it: rescanned anchors or, unless disabled, fallback segments.
+// index and total are only used in progress messages. The chunks of a large region are searched on
+// up to chunkThreads goroutines.
+func processUncoveredRegion(query, ref string, qStart, qEnd, index, total, chunkThreads int, opts CoverageOptions, logf func(format string, args ...any)) []common.Segment {
+	refLen := len(ref)
+	var found []common.Segment
+
+	regionActualLen := (qEnd - qStart) + 1
+	logf("Processing uncovered region %d/%d: query pos %d-%d (length: %d)\n",
+		index+1, total, qStart, qEnd, regionActualLen)
+
+	if regionActualLen < opts.Params.VerySmallRegionThreshold {
+		logf("  Skipping very small region (length: %d)\n", regionActualLen)
+		return nil
+	}
+
+	queryRegionStr := query[qStart : qEnd+1]
+	var regionMatches []common.AnchorMatch // Relative coordinates
+
+	if regionActualLen > 1000 { // Python's threshold for "large region" specific handling
+		logf("  Large region detected, using divide-and-conquer approach\n")
+		regionMatches = FindMatchesInLargeRegion(queryRegionStr, ref, opts.Params, opts.Scheme, 500, 0, 0, chunkThreads) // 500 is from python's call
+	} else {
+		regionMatches = FindMatchesInRegion(queryRegionStr, ref, opts.Params, opts.Scheme, 0, 0)
+	}
+
+	if len(regionMatches) > 0 {
+		logf("  Found %d potential matches for this region\n", len(regionMatches))
+		sort.SliceStable(regionMatches, func(i, j int) bool { // Sort by score; ties prefer longer, earlier, forward matches
+			a, b := regionMatches[i], regionMatches[j]
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+			if lenA, lenB := a.QueryEnd-a.QueryStart, b.QueryEnd-b.QueryStart; lenA != lenB {
+				return lenA > lenB
+			}
+			if a.QueryStart != b.QueryStart {
+				return a.QueryStart < b.QueryStart
+			}
+			if a.Orientation != b.Orientation {
+				return a.Orientation < b.Orientation // 'f' before 'r'
+			}
+			return a.RefStart < b.RefStart
+		})
+
+		// Add matches in score order, each cut back to the part not covered by better ones already
+		// added for this region. Dropping a whole match for a small overlap would leave the rest of
+		// its span to the fallbacks.
+		tempAddedForThisRegion := []common.Segment{}
+		for _, match := range regionMatches {
+			absQStart := match.QueryStart + qStart
+			absQEnd := match.QueryEnd + qStart
+
+			segToAdd, ok := trimToUncovered(common.Segment{
+				QueryStart: absQStart, QueryEnd: absQEnd,
+				RefStart: match.RefStart, RefEnd: match.RefEnd,
+				MapQ:   matching.EstimateMapQ(match, regionMatches, opts.Repeats.RepeatFraction(query[absQStart:absQEnd+1]), opts.Params),
+				Source: common.SourceRegionRescan, Identity: match.Identity,
+			}, match.Orientation == 'r', tempAddedForThisRegion, opts.Params.MinMatchLength)
+			if ok {
+				tempAddedForThisRegion = append(tempAddedForThisRegion, segToAdd)
+				found = append(found, segToAdd)
+			}
+		}
+	} else if opts.NoFallback {
+		logf("  No matches found for region. Leaving it unaligned.\n")
+	} else { // No matches found for region, Python's fallback logic
+		logf("  No matches found for region. Creating fallback segments.\n")
+		if regionActualLen > opts.Params.MaxSegmentSize {
+			chunkStep := opts.Params.SmallSegmentLength
+			for chunkOffset := 0; chunkOffset < regionActualLen; chunkOffset += chunkStep {
+				curChunkStartInRegion := chunkOffset
+				curChunkEndInRegion := int(math.Min(float64(chunkOffset+chunkStep), float64(regionActualLen)))
+				if curChunkEndInRegion <= curChunkStartInRegion {
+					continue
+				}
+
+				absQChunkStart := qStart + curChunkStartInRegion
+				absQChunkEnd := qStart + curChunkEndInRegion - 1
+				chunkStr := query[absQChunkStart : absQChunkEnd+1]
+				chunkActualLen := len(chunkStr)
+
+				bestRStart, bestScore := 0, -1.0
+				sampleStep := int(math.Max(1, float64(refLen/opts.Params.SamplePositionsCount)))
+				if sampleStep == 0 {
+					sampleStep = 1
+				}
+
+				for rPos := 0; rPos <= refLen-chunkActualLen; rPos += sampleStep {
+					refChunkStr := ref[rPos : rPos+chunkActualLen]
+					matchesCount := 0
+					for k := 0; k < chunkActualLen; k++ {
+						if scoring.IsMatch(chunkStr[k], refChunkStr[k]) {
+							matchesCount++
+						}
+					}
+					score := float64(matchesCount) / float64(chunkActualLen)
+					if score > bestScore {
+						bestScore, bestRStart = score, rPos
+					}
+				}
+				found = append(found, common.Segment{
+					QueryStart: absQChunkStart, QueryEnd: absQChunkEnd,
+					RefStart: bestRStart, RefEnd: bestRStart + chunkActualLen - 1,
+					Source: common.SourceSampledFallback, Identity: math.Max(0, bestScore),
+				})
+			}
+		} else { // Smaller region, no matches, add one fallback segment
+			rMapStart := qStart % refLen
+			rMapEnd := rMapStart + regionActualLen - 1
+			if rMapEnd >= refLen { // Ensure it fits
+				rMapEnd = refLen - 1
+				if rMapStart > rMapEnd && regionActualLen <= refLen {
+					rMapStart = 0
+				} // Adjust start if possible
+				if regionActualLen > refLen {
+					rMapStart = 0
+				} // query region longer than ref
+			}
+			found = append(found, common.Segment{
+				QueryStart: qStart, QueryEnd: qEnd, RefStart: rMapStart, RefEnd: rMapEnd,
+				Source:   common.SourceModuloFallback,
+				Identity: ungappedIdentity(query[qStart:qEnd+1], ref[rMapStart:rMapEnd+1]),
+			})
+		}
+	}
+	return found
+}
+
+// trimToUncovered returns the longest part of seg's query span that no segment in placed covers.
+// Its reference span is projected along seg's diagonal from the end of seg it shares, so indels
+// elsewhere in seg do not shift it; reverse selects the anti-diagonal of a reverse-strand match.
+// ok is false when that part is shorter than minLen.
+func trimToUncovered(seg common.Segment, reverse bool, placed []common.Segment, minLen int) (common.Segment, bool) {
+	best := [2]int{seg.QueryStart, seg.QueryStart - 1}
+	free := [][2]int{{seg.QueryStart, seg.QueryEnd}}
+	for _, p := range placed {
+		var rest [][2]int
+		for _, iv := range free {
+			if p.QueryEnd < iv[0] || p.QueryStart > iv[1] {
+				rest = append(rest, iv)
+				continue
+			}
+			if p.QueryStart > iv[0] {
+				rest = append(rest, [2]int{iv[0], p.QueryStart - 1})
+			}
+			if p.QueryEnd < iv[1] {
+				rest = append(rest, [2]int{p.QueryEnd + 1, iv[1]})
+			}
+		}
+		free = rest
+	}
+	for _, iv := range free {
+		if iv[1]-iv[0] > best[1]-best[0] {
+			best = iv
+		}
+	}
+	if best[1]-best[0]+1 < minLen {
+		return seg, false
+	}
+	if best[0] == seg.QueryStart && best[1] == seg.QueryEnd {
+		return seg, true
+	}
+
+	length := best[1] - best[0]
+	// Offsets of the kept part from the start and end of seg, in the order seg walks the reference
+	head, tail := best[0]-seg.QueryStart, seg.QueryEnd-best[1]
+	if reverse {
+		head, tail = tail, head
+	}
+	refStart, refEnd := seg.RefStart+head, seg.RefStart+head+length
+	if tail == 0 {
+		refStart, refEnd = seg.RefEnd-length, seg.RefEnd
+	}
+	seg.QueryStart, seg.QueryEnd = best[0], best[1]
+	seg.RefStart, seg.RefEnd = max(refStart, seg.RefStart), min(refEnd, seg.RefEnd)
+	return seg, true
+}
+
+// ungappedIdentity returns the fraction of positions at which two sequences, laid side by side
+// without gaps, carry the same base. Bases beyond the shorter sequence count as mismatches.
+func ungappedIdentity(a, b string) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 0.0
+	}
+	matches := 0
+	for i := 0; i < min(len(a), len(b)); i++ {
+		if scoring.IsMatch(a[i], b[i]) {
+			matches++
+		}
+	}
+	return float64(matches) / float64(longest)
+}
@@ -4,6 +4,8 @@ import (
 	"DNA-Sequence-Alignments/dna_aligner/common"
 	"DNA-Sequence-Alignments/dna_aligner/config"
 	"DNA-Sequence-Alignments/dna_aligner/matching"
+	"DNA-Sequence-Alignments/dna_aligner/parallel"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
 
 	"math"
 	"sort"
@@ -23,8 +25,8 @@ func FindUncoveredRegions(queryLen int, segments []common.Segment) [][2]int { //
 	// Ensure segments are sorted by query start position (caller should ideally ensure this)
 	sortedSegments := make([]common.Segment, len(segments))
 	copy(sortedSegments, segments) // Avoid modifying original slice if passed by ref
-	sort.Slice(sortedSegments, func(i, j int) bool {
-		return sortedSegments[i].QueryStart < sortedSegments[j].QueryStart
+	sort.SliceStable(sortedSegments, func(i, j int) bool {
+		return common.SegmentLess(sortedSegments[i], sortedSegments[j])
 	})
 
 	var uncovered [][2]int
@@ -46,7 +48,7 @@ func FindUncoveredRegions(queryLen int, segments []common.Segment) [][2]int { //
 
 // findMatchesInRegionCore is a helper for finding matches in a given query region.
 // Coords in returned AnchorMatch are relative to queryRegion string.
-func findMatchesInRegionCore(queryRegion, ref string, minMatchL, maxErr int, kValuesToTry []int, stride int) []common.AnchorMatch {
+func findMatchesInRegionCore(queryRegion, ref string, p *config.Params, scheme *scoring.Scheme, minMatchL, maxErr int, kValuesToTry []int, stride int) []common.AnchorMatch {
 	var regionMatches []common.AnchorMatch
 
 	for _, k := range kValuesToTry {
@@ -55,7 +57,7 @@ func findMatchesInRegionCore(queryRegion, ref string, minMatchL, maxErr int, kVa
 		}
 
 		// Forward anchors
-		fAnchors := matching.FindAnchors(queryRegion, ref, k, minMatchL, stride, maxErr)
+		fAnchors := matching.FindAnchors(queryRegion, ref, p, scheme, k, minMatchL, stride, maxErr)
 		for _, anc := range fAnchors {
 			m := anc // Make a copy to set orientation
 			m.Orientation = 'f'
@@ -63,7 +65,7 @@ func findMatchesInRegionCore(queryRegion, ref string, minMatchL, maxErr int, kVa
 		}
 
 		// Reverse anchors
-		rAnchors := matching.FindReverseAnchors(queryRegion, ref, k, minMatchL, stride, maxErr)
+		rAnchors := matching.FindReverseAnchors(queryRegion, ref, p, scheme, k, minMatchL, stride, maxErr)
 		for _, anc := range rAnchors {
 			m := anc // Make a copy
 			m.Orientation = 'r'
@@ -75,7 +77,7 @@ func findMatchesInRegionCore(queryRegion, ref string, minMatchL, maxErr int, kVa
 
 // FindMatchesInRegion finds matches for a smaller query region against the full reference.
 // Coords in returned AnchorMatch are relative to queryRegion string.
-func FindMatchesInRegion(queryRegion, ref string, minMatchLenUser, maxErrorsUser int) []common.AnchorMatch {
+func FindMatchesInRegion(queryRegion, ref string, p *config.Params, scheme *scoring.Scheme, minMatchLenUser, maxErrorsUser int) []common.AnchorMatch {
 	segmentLen := len(queryRegion)
 	if segmentLen == 0 {
 		return []common.AnchorMatch{}
@@ -83,17 +85,17 @@ func FindMatchesInRegion(queryRegion, ref string, minMatchLenUser, maxErrorsUser
 
 	var kSize int
 	if segmentLen < 50 {
-		kSize = config.SmallKForShortSegments
+		kSize = p.SmallKForShortSegments
 	} else if segmentLen < 100 {
-		kSize = config.MediumKForShortSegments
+		kSize = p.MediumKForShortSegments
 	} else {
-		kSize = config.LargeKForShortSegments
+		kSize = p.LargeKForShortSegments
 	}
 	kValuesToTry := []int{kSize}
 
 	minMatchL := minMatchLenUser
 	if minMatchL == 0 {
-		minMatchL = config.MinMatchLength // Use global default
+		minMatchL = p.MinMatchLength
 	}
 
 	maxErr := maxErrorsUser
@@ -104,12 +106,13 @@ func FindMatchesInRegion(queryRegion, ref string, minMatchLenUser, maxErrorsUser
 		} // Ensure at least 1
 	}
 
-	return findMatchesInRegionCore(queryRegion, ref, minMatchL, maxErr, kValuesToTry, 1) // Stride 1 as in Python
+	return findMatchesInRegionCore(queryRegion, ref, p, scheme, minMatchL, maxErr, kValuesToTry, 1) // Stride 1 as in Python
 }
 
 // FindMatchesInLargeRegion finds multiple matches for a large query region using a divide-and-conquer approach.
+// Chunks are searched on up to threads goroutines; results do not depend on the thread count.
 // Coords in returned AnchorMatch are relative to queryRegion string.
-func FindMatchesInLargeRegion(queryRegion, ref string, maxSegSizeUser, minMatchLenUser, maxErrorsUser int) []common.AnchorMatch {
+func FindMatchesInLargeRegion(queryRegion, ref string, p *config.Params, scheme *scoring.Scheme, maxSegSizeUser, minMatchLenUser, maxErrorsUser, threads int) []common.AnchorMatch {
 	regionLen := len(queryRegion)
 	if regionLen == 0 {
 		return []common.AnchorMatch{}
@@ -117,33 +120,33 @@ func FindMatchesInLargeRegion(queryRegion, ref string, maxSegSizeUser, minMatchL
 
 	maxSegSize := maxSegSizeUser
 	if maxSegSize == 0 {
-		maxSegSize = config.MaxSegmentSize
+		maxSegSize = p.MaxSegmentSize
 	}
 	minMatchL := minMatchLenUser
 	if minMatchL == 0 {
-		minMatchL = config.MinMatchLength
+		minMatchL = p.MinMatchLength
 	}
 	maxErrDefault := maxErrorsUser
 	if maxErrDefault == 0 {
-		maxErrDefault = config.DefaultMaxErrors
+		maxErrDefault = p.DefaultMaxErrors
 	}
 
 	if regionLen <= maxSegSize { // Not "large" enough, use simpler method
-		return FindMatchesInRegion(queryRegion, ref, minMatchL, maxErrDefault)
+		return FindMatchesInRegion(queryRegion, ref, p, scheme, minMatchL, maxErrDefault)
 	}
 
 	var matches []common.AnchorMatch
 	chunkSize, overlap := 0, 0
 
 	if regionLen > 5000 {
-		chunkSize = config.LargeRegionChunkSize
-		overlap = config.LargeRegionOverlap
+		chunkSize = p.LargeRegionChunkSize
+		overlap = p.LargeRegionOverlap
 	} else {
 		chunkSize = maxSegSize
-		overlap = int(float64(chunkSize) / config.StandardChunkOverlapRatio)
+		overlap = int(float64(chunkSize) / p.StandardChunkOverlapRatio)
 	}
 	if chunkSize <= 0 {
-		chunkSize = config.MaxSegmentSize
+		chunkSize = p.MaxSegmentSize
 	}
 	if overlap < 0 {
 		overlap = 0
@@ -162,33 +165,41 @@ func FindMatchesInLargeRegion(queryRegion, ref string, maxSegSizeUser, minMatchL
 		}
 	}
 
+	var chunkStarts []int
 	for i := 0; i < regionLen; i += (chunkSize - overlap) {
+		chunkStarts = append(chunkStarts, i)
+	}
+
+	// Chunks are independent; matches are gathered per chunk and concatenated in chunk order.
+	matchesPerChunk := make([][]common.AnchorMatch, len(chunkStarts))
+	parallel.ForEach(len(chunkStarts), threads, func(c int) {
+		i := chunkStarts[c]
 		chunkStart := i
 		chunkEnd := int(math.Min(float64(i+chunkSize), float64(regionLen)))
 
 		if chunkEnd-chunkStart < minMatchL {
-			continue
+			return
 		}
 		chunk := queryRegion[chunkStart:chunkEnd]
 		chunkLen := len(chunk)
 
 		var kValues []int
 		if chunkLen < 100 {
-			kValues = config.VeryShortSegmentKValues
+			kValues = p.VeryShortSegmentKValues
 		} else if chunkLen < 300 {
-			kValues = config.ShortSegmentKValues
+			kValues = p.ShortSegmentKValues
 		} else {
-			kValues = config.LongerSegmentKValues
+			kValues = p.LongerSegmentKValues
 		}
 
 		currentMaxErrors := maxErrDefault
 		if i == 0 || chunkEnd == regionLen { // Boundary chunks
-			currentMaxErrors += config.BoundaryExtraErrors
+			currentMaxErrors += p.BoundaryExtraErrors
 		}
 
-		chunkMatches := findMatchesInRegionCore(chunk, ref, minMatchL, currentMaxErrors, kValues, 1) // Stride 1
+		chunkMatches := findMatchesInRegionCore(chunk, ref, p, scheme, minMatchL, currentMaxErrors, kValues, 1) // Stride 1
 		for _, m := range chunkMatches {
-			matches = append(matches, common.AnchorMatch{
+			matchesPerChunk[c] = append(matchesPerChunk[c], common.AnchorMatch{
 				QueryStart:  m.QueryStart + chunkStart, // Adjust to queryRegion coordinates
 				QueryEnd:    m.QueryEnd + chunkStart,
 				RefStart:    m.RefStart,
@@ -198,6 +209,9 @@ func FindMatchesInLargeRegion(queryRegion, ref string, maxSegSizeUser, minMatchL
 				Orientation: m.Orientation,
 			})
 		}
+	})
+	for _, chunkMatches := range matchesPerChunk {
+		matches = append(matches, chunkMatches...)
 	}
 
 	// Enhanced filtering (Python logic)
@@ -220,9 +234,9 @@ func FindMatchesInLargeRegion(queryRegion, ref string, maxSegSizeUser, minMatchL
 		qStartI, qEndI := matchI.QueryStart, matchI.QueryEnd
 		lenI := (qEndI - qStartI) + 1
 
-		overlapThresh := config.DefaultOverlapThreshold
+		overlapThresh := p.DefaultOverlapThreshold
 		if matchI.Identity > 0.9 && lenI > 100 {
-			overlapThresh = config.HighQualityOverlapThreshold
+			overlapThresh = p.HighQualityOverlapThreshold
 		}
 
 		for j := 0; j < len(matches); j++ { // Python iterated all j, not just j > i
@@ -252,3 +266,73 @@ func FindMatchesInLargeRegion(queryRegion, ref string, maxSegSizeUser, minMatchL
 	}
 	return filtered
 }
+
+// DepthInterval is a run of reference positions (inclusive) together with the highest coverage depth inside it.
+type DepthInterval struct {
+	Start    int
+	End      int
+	MaxDepth int
+}
+
+// ComputeReferenceCoverage returns, for every reference base, the number of segments whose
+// reference span covers it. Fallback segments, placed without any supporting match, do not count,
+// and positions outside [0, refLen) are ignored.
+func ComputeReferenceCoverage(refLen int, segments []common.Segment) []int {
+	depth := make([]int, refLen)
+	if refLen == 0 {
+		return depth
+	}
+	delta := make([]int, refLen+1) // Difference array: +1 at start, -1 after end
+	for _, seg := range segments {
+		if seg.Source.IsFallback() {
+			continue
+		}
+		start := max(seg.RefStart, 0)
+		end := min(seg.RefEnd, refLen-1)
+		if start > end {
+			continue
+		}
+		delta[start]++
+		delta[end+1]--
+	}
+	running := 0
+	for i := 0; i < refLen; i++ {
+		running += delta[i]
+		depth[i] = running
+	}
+	return depth
+}
+
+// FindUncoveredReferenceRegions returns the reference regions (inclusive) not covered by any segment,
+// given the per-base depth from ComputeReferenceCoverage.
+func FindUncoveredReferenceRegions(depth []int) [][2]int {
+	var uncovered [][2]int
+	for _, iv := range findDepthRuns(depth, func(d int) bool { return d == 0 }) {
+		uncovered = append(uncovered, [2]int{iv.Start, iv.End})
+	}
+	return uncovered
+}
+
+// FindMultiplyCoveredReferenceRegions returns maximal reference regions covered by at least minDepth
+// segments (typically 2). Such regions point to collapsed repeats or duplications in the query.
+func FindMultiplyCoveredReferenceRegions(depth []int, minDepth int) []DepthInterval {
+	return findDepthRuns(depth, func(d int) bool { return d >= minDepth })
+}
+
+// findDepthRuns returns the maximal runs of positions whose depth satisfies keep.
+func findDepthRuns(depth []int, keep func(int) bool) []DepthInterval {
+	var runs []DepthInterval
+	for i := 0; i < len(depth); i++ {
+		if !keep(depth[i]) {
+			continue
+		}
+		run := DepthInterval{Start: i, End: i, MaxDepth: depth[i]}
+		for i+1 < len(depth) && keep(depth[i+1]) {
+			i++
+			run.End = i
+			run.MaxDepth = max(run.MaxDepth, depth[i])
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
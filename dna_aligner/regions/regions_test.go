@@ -0,0 +1,151 @@
+package regions
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func formatSegments(segs []common.Segment) string {
+	var sb strings.Builder
+	for _, s := range segs {
+		fmt.Fprintf(&sb, "  q[%d,%d] r[%d,%d] %s mapq=%d\n", s.QueryStart, s.QueryEnd, s.RefStart, s.RefEnd, s.Source, s.MapQ)
+	}
+	return sb.String()
+}
+
+func TestIntervals(t *testing.T) {
+	segs := []common.Segment{
+		{QueryStart: 40, QueryEnd: 59, RefStart: 0, RefEnd: 19},
+		{QueryStart: 10, QueryEnd: 29, RefStart: 10, RefEnd: 29},
+		{QueryStart: 10, QueryEnd: 49, RefStart: 50, RefEnd: 89},
+		{QueryStart: 70, QueryEnd: 79, RefStart: 15, RefEnd: 24},
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "uncovered query %v\n", FindUncoveredRegions(100, segs))
+	fmt.Fprintf(&sb, "uncovered empty %v\n", FindUncoveredRegions(5, nil))
+	sb.WriteString("resolved\n" + formatSegments(ResolveOverlaps(segs)))
+
+	depth := ComputeReferenceCoverage(100, segs)
+	fmt.Fprintf(&sb, "depth %v\n", depth)
+	fmt.Fprintf(&sb, "uncovered ref %v\n", FindUncoveredReferenceRegions(depth))
+	fmt.Fprintf(&sb, "multiply covered %v\n", FindMultiplyCoveredReferenceRegions(depth, 2))
+	golden.AssertString(t, sb.String())
+
+	fallback := common.Segment{QueryStart: 80, QueryEnd: 99, RefStart: 0, RefEnd: 99, Source: common.SourceModuloFallback}
+	if got := ComputeReferenceCoverage(100, append(segs[:len(segs):len(segs)], fallback)); fmt.Sprint(got) != fmt.Sprint(depth) {
+		t.Errorf("fallback segment counted in the reference depth: %v", got)
+	}
+}
+
+// TestEnsureCompleteCoverage removes one true block per scenario and checks that the coverage
+// pass places rescanned segments on the block's locus over at least half of it, covers the whole
+// query unless fallbacks are disabled, and never invents fallback segments when they are.
+func TestEnsureCompleteCoverage(t *testing.T) {
+	common.LogWriter = io.Discard
+	const tolerance = 20 // Diagonal drift allowed by indels inside the block
+	for _, sc := range simulate.Scenarios {
+		res := sc.Generate(1)
+		truth := res.Segments() // One segment per non-empty block, in block order
+		var blocks []simulate.Block
+		for _, b := range res.Blocks {
+			if b.QueryEnd > b.QueryStart && b.RefEnd > b.RefStart {
+				blocks = append(blocks, b)
+			}
+		}
+		missing := len(truth) / 2
+		removed := blocks[missing]
+		initial := append(append([]common.Segment{}, truth[:missing]...), truth[missing+1:]...)
+
+		for _, noFallback := range []bool{false, true} {
+			got := EnsureCompleteCoverage(res.Query, res.Ref, initial, CoverageOptions{NoFallback: noFallback})
+			refilled := 0
+			for _, seg := range got {
+				if noFallback && seg.Source.IsFallback() {
+					t.Errorf("%s: fallback segment %+v with fallbacks disabled", sc.Name, seg)
+				}
+				// Forward blocks keep ref-query constant, reverse blocks ref+query.
+				want, diag := removed.RefStart-removed.QueryStart, seg.RefStart-seg.QueryStart
+				if removed.Reverse {
+					want, diag = removed.RefEnd-1+removed.QueryStart, seg.RefEnd+seg.QueryStart
+				}
+				if seg.Source == common.SourceRegionRescan && abs(diag-want) <= tolerance {
+					refilled += max(0, min(seg.QueryEnd+1, removed.QueryEnd)-max(seg.QueryStart, removed.QueryStart))
+				}
+			}
+			if 2*refilled < removed.QueryEnd-removed.QueryStart {
+				t.Errorf("%s noFallback=%v: %d bases of removed block %+v refilled:\n%s", sc.Name, noFallback, refilled, removed, formatSegments(got))
+			}
+			if uncovered := FindUncoveredRegions(len(res.Query), got); !noFallback && len(uncovered) > 0 {
+				t.Errorf("%s: uncovered query regions %v", sc.Name, uncovered)
+			}
+		}
+	}
+}
+
+// TestLargeRegionThreads checks that the matches of a large region, and the coverage pass that
+// searches it, do not depend on the number of threads.
+func TestLargeRegionThreads(t *testing.T) {
+	common.LogWriter = io.Discard
+	res := simulate.Scenarios[len(simulate.Scenarios)-1].Generate(2)
+	p := config.Default()
+	scheme := scoring.FromParams(&p)
+	region := res.Query[:min(len(res.Query), 3000)]
+
+	want := FindMatchesInLargeRegion(region, res.Ref, &p, scheme, 500, 0, 0, 1)
+	if len(want) == 0 {
+		t.Fatal("no matches in the region")
+	}
+	for _, threads := range []int{2, 4, 16} {
+		if got := FindMatchesInLargeRegion(region, res.Ref, &p, scheme, 500, 0, 0, threads); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%d threads: matches differ from one thread:\n%v\nvs\n%v", threads, got, want)
+		}
+	}
+
+	// Without initial segments the whole query is one large uncovered region.
+	wantSegs := formatSegments(EnsureCompleteCoverage(res.Query, res.Ref, nil, CoverageOptions{Threads: 1}))
+	for _, threads := range []int{2, 8} {
+		if got := formatSegments(EnsureCompleteCoverage(res.Query, res.Ref, nil, CoverageOptions{Threads: threads})); got != wantSegs {
+			t.Errorf("%d threads: coverage differs from one thread:\n%s\nvs\n%s", threads, got, wantSegs)
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func TestTrimToUncovered(t *testing.T) {
+	placed := []common.Segment{{QueryStart: 0, QueryEnd: 49}, {QueryStart: 120, QueryEnd: 129}}
+	for _, tc := range []struct {
+		seg     common.Segment
+		reverse bool
+		want    string
+	}{
+		// The suffix 50-119 is kept on the diagonal of the segment's end, past an indel.
+		{common.Segment{QueryStart: 30, QueryEnd: 119, RefStart: 1000, RefEnd: 1091}, false, "q[50,119] r[1022,1091]"},
+		// On the reverse strand the query end maps to the reference start.
+		{common.Segment{QueryStart: 30, QueryEnd: 119, RefStart: 1000, RefEnd: 1089}, true, "q[50,119] r[1000,1069]"},
+		// The longest free part lies between the placed segments.
+		{common.Segment{QueryStart: 40, QueryEnd: 140, RefStart: 500, RefEnd: 600}, false, "q[50,119] r[510,579]"},
+		{common.Segment{QueryStart: 200, QueryEnd: 260, RefStart: 10, RefEnd: 70}, false, "q[200,260] r[10,70]"},
+		{common.Segment{QueryStart: 110, QueryEnd: 140, RefStart: 10, RefEnd: 40}, false, "dropped"},
+	} {
+		got := "dropped"
+		if seg, ok := trimToUncovered(tc.seg, tc.reverse, placed, 20); ok {
+			got = fmt.Sprintf("q[%d,%d] r[%d,%d]", seg.QueryStart, seg.QueryEnd, seg.RefStart, seg.RefEnd)
+		}
+		if got != tc.want {
+			t.Errorf("trimToUncovered(%+v, reverse=%v) = %s, want %s", tc.seg, tc.reverse, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/regions"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// Summary aggregates the statistics of an alignment result.
+type Summary struct {
+	QueryLength       int            `json:"query_length"`
+	RefLength         int            `json:"ref_length"`
+	SegmentCount      int            `json:"segment_count"`
+	AlignedQueryBases int            `json:"aligned_query_bases"`
+	AlignedRefBases   int            `json:"aligned_ref_bases"`
+	MultiRefBases     int            `json:"multiply_covered_ref_bases"` // Reference bases hit by two or more segments; fallbacks excluded
+	MaxRefDepth       int            `json:"max_ref_depth"`
+	QueryCoverage     float64        `json:"query_coverage"`
+	RefCoverage       float64        `json:"ref_coverage"`
+	WeightedIdentity  float64        `json:"weighted_identity"` // Total matches over total alignment columns
+	N50               int            `json:"n50"`               // Over the query lengths of the segments
+	Matches           int            `json:"matches"`
+	Mismatches        int            `json:"mismatches"`
+	Insertions        int            `json:"insertions"`
+	Deletions         int            `json:"deletions"`
+	SegmentsBySource  map[string]int `json:"segments_by_source"`
+	Segments          []SegmentStats `json:"segments"`
+	Config            *config.Params `json:"config,omitempty"` // Parameters the alignment ran with
+}
+
+// SegmentStats is the per-segment part of the report. End coordinates are exclusive, as in the result files.
+type SegmentStats struct {
+	QueryStart int     `json:"query_start"`
+	QueryEnd   int     `json:"query_end"`
+	RefStart   int     `json:"ref_start"`
+	RefEnd     int     `json:"ref_end"`
+	Matches    int     `json:"matches"`
+	Mismatches int     `json:"mismatches"`
+	Insertions int     `json:"insertions"`
+	Deletions  int     `json:"deletions"`
+	Identity   float64 `json:"identity"`
+	Score      int     `json:"score"`
+	BitScore   float64 `json:"bit_score"`
+	EValue     float64 `json:"evalue"`
+	MapQ       int     `json:"mapq"`
+	Stage      string  `json:"stage"`
+}
+
+// Summarize computes the report for segments aligned between a query and a reference of the given lengths.
+// Segments are expected to carry base-level statistics (see aligner.FindAlignment).
+func Summarize(queryLen, refLen int, segments []common.Segment) Summary {
+	s := Summary{
+		QueryLength:      queryLen,
+		RefLength:        refLen,
+		SegmentCount:     len(segments),
+		SegmentsBySource: make(map[string]int),
+		Segments:         make([]SegmentStats, 0, len(segments)),
+	}
+
+	lengths := make([]int, 0, len(segments))
+	for _, seg := range segments {
+		lengths = append(lengths, seg.QueryEnd-seg.QueryStart+1)
+
+		s.Matches += seg.Matches
+		s.Mismatches += seg.Mismatches
+		s.Insertions += seg.Insertions
+		s.Deletions += seg.Deletions
+		s.SegmentsBySource[seg.Source.String()]++
+		s.Segments = append(s.Segments, SegmentStats{
+			QueryStart: seg.QueryStart, QueryEnd: seg.QueryEnd + 1,
+			RefStart: seg.RefStart, RefEnd: seg.RefEnd + 1,
+			Matches: seg.Matches, Mismatches: seg.Mismatches,
+			Insertions: seg.Insertions, Deletions: seg.Deletions,
+			Identity: seg.Identity, Score: seg.Score, MapQ: seg.MapQ,
+			BitScore: seg.BitScore, EValue: seg.EValue,
+			Stage: seg.Source.String(),
+		})
+	}
+
+	s.AlignedQueryBases = queryLen
+	for _, reg := range regions.FindUncoveredRegions(queryLen, segments) {
+		s.AlignedQueryBases -= reg[1] - reg[0] + 1
+	}
+	for _, d := range regions.ComputeReferenceCoverage(refLen, segments) {
+		if d > 0 {
+			s.AlignedRefBases++
+		}
+		if d > 1 {
+			s.MultiRefBases++
+		}
+		s.MaxRefDepth = max(s.MaxRefDepth, d)
+	}
+	if queryLen > 0 {
+		s.QueryCoverage = float64(s.AlignedQueryBases) / float64(queryLen)
+	}
+	if refLen > 0 {
+		s.RefCoverage = float64(s.AlignedRefBases) / float64(refLen)
+	}
+	if columns := s.Matches + s.Mismatches + s.Insertions + s.Deletions; columns > 0 {
+		s.WeightedIdentity = float64(s.Matches) / float64(columns)
+	}
+	s.N50 = n50(lengths)
+	return s
+}
+
+// WriteJSON writes the summary as indented JSON.
+func WriteJSON(w io.Writer, s Summary) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// n50 returns the largest length L such that segments of length >= L hold at least half of the total.
+func n50(lengths []int) int {
+	sorted := make([]int, len(lengths))
+	copy(sorted, lengths)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+	total := 0
+	for _, l := range sorted {
+		total += l
+	}
+	running := 0
+	for _, l := range sorted {
+		running += l
+		if 2*running >= total {
+			return l
+		}
+	}
+	return 0
+}
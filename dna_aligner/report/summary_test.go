@@ -0,0 +1,40 @@
+package report
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/internal/golden"
+	"bytes"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	segs := []common.Segment{
+		{QueryStart: 0, QueryEnd: 499, RefStart: 0, RefEnd: 501, MapQ: 60, Source: common.SourceAnchor, Identity: 0.99,
+			Matches: 495, Mismatches: 3, Insertions: 2, Deletions: 4, Score: 970},
+		{QueryStart: 500, QueryEnd: 699, RefStart: 400, RefEnd: 599, MapQ: 30, Source: common.SourceRegionRescan, Identity: 0.97,
+			Matches: 194, Mismatches: 6, Score: 364},
+		{QueryStart: 750, QueryEnd: 799, RefStart: 900, RefEnd: 949, Source: common.SourceModuloFallback, Identity: 0.6,
+			Matches: 30, Mismatches: 20, Score: -20},
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, Summarize(800, 1000, segs)); err != nil {
+		t.Fatal(err)
+	}
+	golden.Assert(t, buf.Bytes())
+}
+
+func TestN50(t *testing.T) {
+	for _, c := range []struct {
+		lengths []int
+		want    int
+	}{
+		{nil, 0},
+		{[]int{10}, 10},
+		{[]int{2, 3, 4, 5, 6, 7, 8, 9, 10}, 8},
+		{[]int{100, 1, 1, 1}, 100},
+	} {
+		if got := n50(c.lengths); got != c.want {
+			t.Errorf("n50(%v) = %d, want %d", c.lengths, got, c.want)
+		}
+	}
+}
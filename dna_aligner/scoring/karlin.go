@@ -0,0 +1,143 @@
+package scoring
+
+import (
+	"errors"
+	"math"
+)
+
+// UniformFrequencies are equal background frequencies of A, C, G and T.
+var UniformFrequencies = [4]float64{0.25, 0.25, 0.25, 0.25}
+
+// KarlinAltschul holds the statistical parameters of a scoring scheme.
+// Lambda and K are those of ungapped local alignment; with gaps they are an approximation
+// (gapped parameters can only be estimated by simulation), which tends to make E-values of
+// gapped alignments slightly optimistic.
+type KarlinAltschul struct {
+	Lambda float64
+	K      float64
+	H      float64 // Relative entropy per aligned column, in nats
+}
+
+// Statistics computes the Karlin-Altschul parameters of the substitution scores of s for
+// sequences with the given background frequencies of A, C, G and T.
+// The expected score of a column must be negative and some column must score positively.
+func (s *Scheme) Statistics(freqs [4]float64) (KarlinAltschul, error) {
+	// Distribution of the score of a random column.
+	low, high := math.MaxInt, math.MinInt
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			low, high = min(low, s.baseScore(i, j)), max(high, s.baseScore(i, j))
+		}
+	}
+	if high <= 0 || low >= 0 {
+		return KarlinAltschul{}, errors.New("scores must include both positive and negative values")
+	}
+	prob := make([]float64, high-low+1) // prob[score-low]
+	expected := 0.0
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			p := freqs[i] * freqs[j]
+			prob[s.baseScore(i, j)-low] += p
+			expected += p * float64(s.baseScore(i, j))
+		}
+	}
+	if expected >= 0 {
+		return KarlinAltschul{}, errors.New("the expected column score must be negative")
+	}
+
+	var ka KarlinAltschul
+	ka.Lambda = solveLambda(prob, low)
+	for k, p := range prob {
+		score := float64(k + low)
+		ka.H += p * score * math.Exp(ka.Lambda*score)
+	}
+	ka.H *= ka.Lambda
+
+	// K = lambda * exp(-2*sigma) / (H * (1 - exp(-lambda))), for integer scores with span 1,
+	// where sigma = sum_k 1/k * (E[exp(lambda*S_k); S_k < 0] + P(S_k >= 0)) over the sums S_k
+	// of k random column scores (Karlin & Altschul 1990).
+	g := 0
+	for k := range prob {
+		if prob[k] > 0 {
+			g = gcd(g, k+low)
+		}
+	}
+	dist := []float64{1} // Distribution of S_k, indexed by S_k - k*low
+	sigma := 0.0
+	for k := 1; k <= 200; k++ {
+		next := make([]float64, len(dist)+len(prob)-1)
+		for a, pa := range dist {
+			if pa == 0 {
+				continue
+			}
+			for b, pb := range prob {
+				next[a+b] += pa * pb
+			}
+		}
+		dist = next
+		term := 0.0
+		for idx, p := range dist {
+			sum := idx + k*low
+			if sum < 0 {
+				term += p * math.Exp(ka.Lambda*float64(sum))
+			} else {
+				term += p
+			}
+		}
+		sigma += term / float64(k)
+		if term/float64(k) < 1e-10 {
+			break
+		}
+	}
+	span := float64(g)
+	ka.K = ka.Lambda * span * math.Exp(-2*sigma) / (ka.H * (1 - math.Exp(-ka.Lambda*span)))
+	return ka, nil
+}
+
+// solveLambda finds the positive root of sum_s prob(s) * exp(lambda*s) = 1 by bisection.
+func solveLambda(prob []float64, low int) float64 {
+	f := func(lambda float64) float64 {
+		sum := 0.0
+		for k, p := range prob {
+			sum += p * math.Exp(lambda*float64(k+low))
+		}
+		return sum - 1
+	}
+	lo, hi := 0.0, 1.0
+	for f(hi) < 0 {
+		hi *= 2
+	}
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if f(mid) < 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+func gcd(a, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// BitScore converts a raw score to bits.
+func (ka KarlinAltschul) BitScore(score float64) float64 {
+	return (ka.Lambda*score - math.Log(ka.K)) / math.Ln2
+}
+
+// EValue returns the expected number of local alignments scoring at least score between
+// random sequences of lengths m and n.
+func (ka KarlinAltschul) EValue(score float64, m, n int) float64 {
+	return ka.K * float64(m) * float64(n) * math.Exp(-ka.Lambda*score)
+}
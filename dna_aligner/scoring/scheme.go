@@ -0,0 +1,126 @@
+// Package scoring defines the alignment scoring scheme shared by anchor extension, base-level
+// alignment and identity: a nucleotide substitution matrix with transition/transversion
+// weighting and IUPAC ambiguity codes, affine gap costs and Karlin-Altschul statistics.
+package scoring
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"math"
+)
+
+// iupac maps every IUPAC nucleotide code (upper case) to the set of bases it stands for,
+// as a bit mask over A=1, C=2, G=4, T=8.
+var iupac = map[byte]uint8{
+	'A': 1, 'C': 2, 'G': 4, 'T': 8, 'U': 8,
+	'R': 1 | 4, 'Y': 2 | 8, 'S': 2 | 4, 'W': 1 | 8, 'K': 4 | 8, 'M': 1 | 2,
+	'B': 2 | 4 | 8, 'D': 1 | 4 | 8, 'H': 1 | 2 | 8, 'V': 1 | 2 | 4,
+	'N': 15,
+}
+
+// Scheme scores alignment columns. Gap costs are positive: a gap of length L costs
+// GapOpen + L*GapExtend.
+type Scheme struct {
+	Match        int // Identical bases
+	Transition   int // A<->G or C<->T
+	Transversion int // Any other substitution
+	Ambiguous    int // A column involving N or a character that is not a nucleotide code
+	GapOpen      int
+	GapExtend    int
+
+	table [256][256]int
+}
+
+// New builds a scheme. A column holding a partially ambiguous code (e.g. R against A) scores
+// the average over the bases the codes stand for, rounded to the nearest integer.
+func New(match, transition, transversion, ambiguous, gapOpen, gapExtend int) *Scheme {
+	s := &Scheme{Match: match, Transition: transition, Transversion: transversion, Ambiguous: ambiguous, GapOpen: gapOpen, GapExtend: gapExtend}
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			s.table[a][b] = s.columnScore(upper(byte(a)), upper(byte(b)))
+		}
+	}
+	return s
+}
+
+// FromParams builds the scheme selected by the Pairwise* parameters of p. Filling the
+// substitution table is not free: build the scheme once per alignment and pass it on.
+func FromParams(p *config.Params) *Scheme {
+	return New(p.PairwiseMatchScore, p.PairwiseTransitionScore, p.PairwiseMismatchScore,
+		p.PairwiseAmbiguousScore, p.PairwiseGapOpen, p.PairwiseGapExtend)
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+func (s *Scheme) columnScore(a, b byte) int {
+	maskA, okA := iupac[a]
+	maskB, okB := iupac[b]
+	if !okA || !okB || a == 'N' || b == 'N' {
+		return s.Ambiguous
+	}
+	total, n := 0, 0
+	for i := 0; i < 4; i++ {
+		if maskA&(1<<i) == 0 {
+			continue
+		}
+		for j := 0; j < 4; j++ {
+			if maskB&(1<<j) != 0 {
+				total += s.baseScore(i, j)
+				n++
+			}
+		}
+	}
+	return int(math.Round(float64(total) / float64(n)))
+}
+
+// baseScore scores two unambiguous bases given by their index in ACGT.
+func (s *Scheme) baseScore(i, j int) int {
+	switch {
+	case i == j:
+		return s.Match
+	case i^j == 2: // A(0)<->G(2) and C(1)<->T(3)
+		return s.Transition
+	}
+	return s.Transversion
+}
+
+// Score returns the score of aligning base a against base b (case-insensitive).
+func (s *Scheme) Score(a, b byte) int {
+	return s.table[a][b]
+}
+
+// Gap returns the cost of a gap of the given length (0 for an empty gap).
+func (s *Scheme) Gap(length int) int {
+	if length <= 0 {
+		return 0
+	}
+	return s.GapOpen + length*s.GapExtend
+}
+
+// nucleotide marks the unambiguous base characters, in either case.
+var nucleotide [256]bool
+
+func init() {
+	for _, c := range "ACGTUacgtu" {
+		nucleotide[c] = true
+	}
+}
+
+// IsMatch reports whether a and b are the same nucleotide. Ambiguity codes never match,
+// so identity only counts columns where both bases are known and equal. U and T are the same
+// base, as in the substitution matrix.
+func IsMatch(a, b byte) bool {
+	return nucleotide[a] && nucleotide[b] && toDNA(a) == toDNA(b)
+}
+
+// toDNA upper-cases a base and writes U as T.
+func toDNA(c byte) byte {
+	if c = upper(c); c == 'U' {
+		return 'T'
+	}
+	return c
+}
@@ -0,0 +1,113 @@
+package scoring
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestDefaultMatrix pins the substitution matrix of the default parameters.
+func TestDefaultMatrix(t *testing.T) {
+	p := config.Default()
+	s := FromParams(&p)
+	const codes = "ACGTRYNX"
+	var sb strings.Builder
+	sb.WriteString(" ")
+	for _, b := range codes {
+		fmt.Fprintf(&sb, "%4c", b)
+	}
+	sb.WriteString("\n")
+	for _, a := range codes {
+		fmt.Fprintf(&sb, "%c", a)
+		for _, b := range codes {
+			fmt.Fprintf(&sb, "%4d", s.Score(byte(a), byte(b)))
+		}
+		sb.WriteString("\n")
+	}
+	golden.AssertString(t, sb.String())
+}
+
+func TestScheme(t *testing.T) {
+	s := New(2, -1, -3, -2, 5, 1)
+	for _, tc := range []struct {
+		a, b byte
+		want int
+	}{
+		{'A', 'A', 2}, {'a', 'A', 2}, {'A', 'G', -1}, {'C', 't', -1}, {'A', 'C', -3}, {'G', 'T', -3},
+		{'N', 'A', -2}, {'N', 'N', -2}, {'-', 'A', -2},
+		{'R', 'A', 1},  // (2 - 1) / 2, halves rounded away from zero
+		{'R', 'C', -3}, // Both R bases are transversions of C
+		{'S', 'S', -1}, // (2 - 3 - 3 + 2) / 4
+	} {
+		if got := s.Score(tc.a, tc.b); got != tc.want {
+			t.Errorf("Score(%c, %c) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+	if s.Gap(0) != 0 || s.Gap(3) != 8 {
+		t.Errorf("Gap(0) = %d, Gap(3) = %d, want 0 and 8", s.Gap(0), s.Gap(3))
+	}
+	if !IsMatch('a', 'A') || IsMatch('N', 'N') || IsMatch('R', 'R') || IsMatch('A', 'C') {
+		t.Errorf("IsMatch must accept equal known bases only")
+	}
+	for _, pair := range []string{"UT", "TU", "uT", "Ut", "UU"} {
+		if !IsMatch(pair[0], pair[1]) || s.Score(pair[0], pair[1]) != s.Match {
+			t.Errorf("%c against %c: IsMatch %v, score %d; want a match scoring %d", pair[0], pair[1], IsMatch(pair[0], pair[1]), s.Score(pair[0], pair[1]), s.Match)
+		}
+	}
+}
+
+func TestFromParamsFollowsParameters(t *testing.T) {
+	p := config.Default()
+	p.PairwiseTransitionScore = -1
+	if got := FromParams(&p).Score('A', 'G'); got != -1 {
+		t.Errorf("transition score %d after changing the parameter, want -1", got)
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	// Ungapped values for uniform base frequencies, as tabulated by BLAST.
+	for _, tc := range []struct {
+		match, mismatch int
+		lambda, k       float64
+	}{
+		{1, -3, 1.374, 0.711},
+		{2, -3, 0.634, 0.408},
+		{1, -1, math.Log(3), 1.0 / 3},
+	} {
+		ka, err := New(tc.match, tc.mismatch, tc.mismatch, -1, 5, 2).Statistics(UniformFrequencies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(ka.Lambda-tc.lambda) > 0.001 || math.Abs(ka.K-tc.k) > 0.001 {
+			t.Errorf("%d/%d: lambda %.4f K %.4f, want %.3f and %.3f", tc.match, tc.mismatch, ka.Lambda, ka.K, tc.lambda, tc.k)
+		}
+	}
+
+	// Scaling every score leaves K unchanged and divides lambda.
+	a, _ := New(1, -2, -2, -1, 5, 2).Statistics(UniformFrequencies)
+	b, _ := New(2, -4, -4, -1, 5, 2).Statistics(UniformFrequencies)
+	if math.Abs(a.Lambda-2*b.Lambda) > 1e-6 || math.Abs(a.K-b.K) > 1e-6 {
+		t.Errorf("scaled scheme: %+v vs %+v", a, b)
+	}
+
+	if _, err := New(1, 1, 1, -1, 5, 2).Statistics(UniformFrequencies); err == nil {
+		t.Errorf("expected an error without negative scores")
+	}
+	if _, err := New(3, -1, -1, -1, 5, 2).Statistics(UniformFrequencies); err == nil {
+		t.Errorf("expected an error for a positive expected score")
+	}
+
+	ka, _ := New(1, -3, -3, -1, 5, 2).Statistics(UniformFrequencies)
+	if e := ka.EValue(30, 1000, 1000); e > 1e-10 || e <= 0 {
+		t.Errorf("EValue(30) = %g", e)
+	}
+	if e1, e2 := ka.EValue(20, 1000, 1000), ka.EValue(20, 2000, 1000); math.Abs(e2/e1-2) > 1e-9 {
+		t.Errorf("E-value does not scale with the search space")
+	}
+	if bits := ka.BitScore(30); math.Abs(bits-(ka.Lambda*30-math.Log(ka.K))/math.Ln2) > 1e-9 || bits < 59 {
+		t.Errorf("BitScore(30) = %.2f", bits)
+	}
+}
@@ -0,0 +1,21 @@
+package sequence
+
+import (
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReverseComplementAndGC(t *testing.T) {
+	var sb strings.Builder
+	for _, seq := range []string{"", "A", "ACGT", "AACCGGTTN", "acgtX", "GGGCCCAT"} {
+		rc := ReverseComplement(seq)
+		fmt.Fprintf(&sb, "%q rc=%q gc=%.3f\n", seq, rc, CalculateGCContent(seq))
+	}
+	golden.AssertString(t, sb.String())
+
+	if seq := "ACGTTGCAAN"; ReverseComplement(ReverseComplement(seq)) != seq {
+		t.Errorf("reverse complement is not an involution on %s", seq)
+	}
+}
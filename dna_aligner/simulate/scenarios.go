@@ -0,0 +1,27 @@
+package simulate
+
+import "math/rand"
+
+// Scenario is a named reference length, GC content and mutation model.
+type Scenario struct {
+	Name      string
+	RefLength int
+	GC        float64
+	Model     Model
+}
+
+// Scenarios each isolate one kind of difference; tests use them as known-answer inputs.
+var Scenarios = []Scenario{
+	{Name: "substitutions", RefLength: 3000, GC: 0.45, Model: Model{SNPRate: 0.02}},
+	{Name: "indels", RefLength: 3000, GC: 0.45, Model: Model{IndelRate: 0.004, MaxIndelLength: 6}},
+	{Name: "inversion", RefLength: 3000, GC: 0.45, Model: Model{SNPRate: 0.005, Inversions: 1, InversionLength: 600}},
+	{Name: "duplication", RefLength: 3000, GC: 0.45, Model: Model{SNPRate: 0.005, Duplications: 1, DuplicationLength: 400}},
+	{Name: "rearrangements", RefLength: 4000, GC: 0.45, Model: Model{SNPRate: 0.005, InterspersedDuplications: 1, DuplicationLength: 300, Translocations: 1, TranslocationLength: 500}},
+	{Name: "mixed", RefLength: 4000, GC: 0.5, Model: Model{SNPRate: 0.01, IndelRate: 0.002, MaxIndelLength: 4, Inversions: 1, InversionLength: 500, Duplications: 1, DuplicationLength: 300}},
+}
+
+// Generate draws a random reference and mutates it, all from the given seed.
+func (s Scenario) Generate(seed int64) Result {
+	rng := rand.New(rand.NewSource(seed))
+	return Mutate(rng, RandomSequence(rng, s.RefLength, s.GC), s.Model)
+}
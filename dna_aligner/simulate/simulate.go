@@ -0,0 +1,291 @@
+// Package simulate generates synthetic reference/query pairs with known differences,
+// together with the true query-to-reference mapping.
+package simulate
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Event kinds
+const (
+	EventSNP                     = "snp"
+	EventInsertion               = "insertion"
+	EventDeletion                = "deletion"
+	EventInversion               = "inversion"
+	EventTandemDuplication       = "tandem-duplication"       // The copy directly follows the original
+	EventInterspersedDuplication = "interspersed-duplication" // The copy is inserted elsewhere
+	EventTranslocation           = "translocation"            // The interval is moved elsewhere
+)
+
+// Model describes the mutations applied to a reference to obtain a query.
+// Rates are per reference base; structural events are counts with a fixed length.
+type Model struct {
+	SNPRate         float64
+	IndelRate       float64
+	MaxIndelLength  int     // Indel lengths are uniform in [1, MaxIndelLength]...
+	IndelLengthMean float64 // ...or, when > 0, geometric with this mean, capped at MaxIndelLength
+
+	Inversions               int
+	InversionLength          int
+	Duplications             int // Tandem duplications
+	DuplicationLength        int // Length of tandem and interspersed duplications
+	InterspersedDuplications int
+	Translocations           int
+	TranslocationLength      int
+}
+
+// Event is one applied mutation. Coordinates are 0-based and half-open; an insertion has an empty
+// reference interval and a deletion an empty query interval. For a duplication the query interval
+// is the added copy, for a translocation the moved interval.
+type Event struct {
+	Kind       string
+	RefStart   int
+	RefEnd     int
+	QueryStart int
+	QueryEnd   int
+}
+
+// Block is a piece of the query copied from one reference interval (half-open coordinates).
+// Small variants inside a block do not split it.
+type Block struct {
+	QueryStart int
+	QueryEnd   int
+	RefStart   int
+	RefEnd     int
+	Reverse    bool
+}
+
+// Result is a simulated pair with its truth.
+type Result struct {
+	Ref    string
+	Query  string
+	Blocks []Block
+	Events []Event
+}
+
+// Segments returns the true mapping in the aligner's segment format (inclusive ends).
+func (r Result) Segments() []common.Segment {
+	segs := make([]common.Segment, 0, len(r.Blocks))
+	for _, b := range r.Blocks {
+		if b.QueryEnd <= b.QueryStart || b.RefEnd <= b.RefStart {
+			continue
+		}
+		segs = append(segs, common.Segment{QueryStart: b.QueryStart, QueryEnd: b.QueryEnd - 1, RefStart: b.RefStart, RefEnd: b.RefEnd - 1})
+	}
+	return segs
+}
+
+// RandomSequence returns n random bases with the given GC fraction.
+func RandomSequence(rng *rand.Rand, n int, gc float64) string {
+	var sb strings.Builder
+	sb.Grow(n)
+	for i := 0; i < n; i++ {
+		sb.WriteByte(randomBase(rng, gc))
+	}
+	return sb.String()
+}
+
+func randomBase(rng *rand.Rand, gc float64) byte {
+	if rng.Float64() < gc {
+		return "GC"[rng.Intn(2)]
+	}
+	return "AT"[rng.Intn(2)]
+}
+
+// Mutate applies model to ref and returns the query with the true mapping.
+// The same rng state always gives the same result.
+func Mutate(rng *rand.Rand, ref string, model Model) Result {
+	res := Result{Ref: ref}
+	blocks, products := structuralBlocks(rng, len(ref), model, &res.Events)
+	var query strings.Builder
+	for i, b := range blocks {
+		b.QueryStart = query.Len()
+		res.Events = append(res.Events, mutateBlock(rng, ref, b, model, &query)...)
+		b.QueryEnd = query.Len()
+		res.Blocks = append(res.Blocks, b)
+		if products[i] >= 0 {
+			// Structural events learn their query interval from the block they produced.
+			res.Events[products[i]].QueryStart, res.Events[products[i]].QueryEnd = b.QueryStart, b.QueryEnd
+		}
+	}
+	res.Query = query.String()
+	sort.SliceStable(res.Events, func(i, j int) bool { return res.Events[i].QueryStart < res.Events[j].QueryStart })
+	return res
+}
+
+// structuralEvent is a structural variant on the reference interval [start, end).
+// Interspersed duplications and translocations insert their interval at reference position at.
+type structuralEvent struct {
+	kind       string
+	start, end int
+	at         int
+}
+
+// structuralBlocks places non-overlapping structural events on the reference and returns the
+// resulting block layout of the query (query coordinates not yet set). products[i] is the index
+// in events of the structural event that produced block i, or -1.
+func structuralBlocks(rng *rand.Rand, refLen int, model Model, events *[]Event) (blocks []Block, products []int) {
+	var svs []structuralEvent
+	inside := func(p int, sv structuralEvent) bool { return sv.start < p && p < sv.end }
+	place := func(kind string, count, length int) {
+		for n := 0; n < count; n++ {
+			for attempt := 0; attempt < 100 && length > 0 && length < refLen; attempt++ {
+				cand := structuralEvent{kind: kind, start: rng.Intn(refLen - length), at: -1}
+				cand.end = cand.start + length
+				if kind == EventInterspersedDuplication || kind == EventTranslocation {
+					cand.at = rng.Intn(refLen + 1)
+				}
+				ok := cand.at < 0 || cand.at < cand.start || cand.at > cand.end
+				for _, sv := range svs {
+					if !ok {
+						break
+					}
+					ok = !(cand.start < sv.end && sv.start < cand.end) && !inside(cand.at, sv) && !inside(sv.at, cand)
+				}
+				if ok {
+					svs = append(svs, cand)
+					break
+				}
+			}
+		}
+	}
+	place(EventInversion, model.Inversions, model.InversionLength)
+	place(EventTandemDuplication, model.Duplications, model.DuplicationLength)
+	place(EventInterspersedDuplication, model.InterspersedDuplications, model.DuplicationLength)
+	place(EventTranslocation, model.Translocations, model.TranslocationLength)
+	sort.SliceStable(svs, func(i, j int) bool { return svs[i].start < svs[j].start })
+
+	base := len(*events)
+	for _, sv := range svs {
+		*events = append(*events, Event{Kind: sv.kind, RefStart: sv.start, RefEnd: sv.end})
+	}
+
+	emit := func(b Block, product int) {
+		// Untouched neighbouring pieces of the reference form a single block.
+		if n := len(blocks); n > 0 && product < 0 && products[n-1] < 0 && !b.Reverse && !blocks[n-1].Reverse && blocks[n-1].RefEnd == b.RefStart {
+			blocks[n-1].RefEnd = b.RefEnd
+			return
+		}
+		blocks = append(blocks, b)
+		products = append(products, product)
+	}
+	insertAt := func(p int) {
+		for i, sv := range svs {
+			if sv.at == p {
+				emit(Block{RefStart: sv.start, RefEnd: sv.end}, base+i)
+			}
+		}
+	}
+
+	cuts := []int{0, refLen}
+	for _, sv := range svs {
+		cuts = append(cuts, sv.start, sv.end)
+		if sv.at >= 0 {
+			cuts = append(cuts, sv.at)
+		}
+	}
+	sort.Ints(cuts)
+	for c := 0; c+1 < len(cuts); c++ {
+		lo, hi := cuts[c], cuts[c+1]
+		if c == 0 || cuts[c-1] != lo {
+			insertAt(lo)
+		}
+		if lo == hi {
+			continue
+		}
+		piece, product := Block{RefStart: lo, RefEnd: hi}, -1
+		for i, sv := range svs {
+			if sv.start <= lo && hi <= sv.end {
+				product = base + i // Events never contain cuts, so the piece is the whole interval
+				break
+			}
+		}
+		switch {
+		case product < 0:
+			emit(piece, -1)
+		case svs[product-base].kind == EventInversion:
+			piece.Reverse = true
+			emit(piece, product)
+		case svs[product-base].kind == EventTandemDuplication:
+			emit(piece, -1)
+			emit(piece, product)
+		case svs[product-base].kind == EventInterspersedDuplication:
+			emit(piece, -1)
+		}
+		// A translocated interval is only emitted at its insertion point.
+	}
+	insertAt(refLen)
+	return blocks, products
+}
+
+// mutateBlock writes the block's bases to query with SNPs and indels applied and returns those events.
+func mutateBlock(rng *rand.Rand, ref string, b Block, model Model, query *strings.Builder) []Event {
+	src := ref[b.RefStart:b.RefEnd]
+	if b.Reverse {
+		src = sequence.ReverseComplement(src)
+	}
+	// refPos maps an offset in src to the reference coordinate of that base.
+	refPos := func(i int) int {
+		if b.Reverse {
+			return b.RefEnd - 1 - i
+		}
+		return b.RefStart + i
+	}
+
+	var events []Event
+	for i := 0; i < len(src); {
+		if model.IndelRate > 0 && (model.MaxIndelLength > 0 || model.IndelLengthMean > 0) && i > 0 && rng.Float64() < model.IndelRate {
+			length := indelLength(rng, model)
+			if rng.Intn(2) == 0 {
+				at := refPos(i) // Reference boundary before src[i]
+				if b.Reverse {
+					at++
+				}
+				qStart := query.Len()
+				query.WriteString(RandomSequence(rng, length, 0.5))
+				events = append(events, Event{Kind: EventInsertion, RefStart: at, RefEnd: at, QueryStart: qStart, QueryEnd: query.Len()})
+				continue
+			}
+			if i+length < len(src) {
+				start, end := refPos(i), refPos(i+length-1)
+				events = append(events, Event{Kind: EventDeletion, RefStart: min(start, end), RefEnd: max(start, end) + 1, QueryStart: query.Len(), QueryEnd: query.Len()})
+				i += length
+				continue
+			}
+		}
+
+		base := src[i]
+		if model.SNPRate > 0 && rng.Float64() < model.SNPRate {
+			alt := "ACGT"[rng.Intn(4)]
+			for alt == base {
+				alt = "ACGT"[rng.Intn(4)]
+			}
+			events = append(events, Event{Kind: EventSNP, RefStart: refPos(i), RefEnd: refPos(i) + 1, QueryStart: query.Len(), QueryEnd: query.Len() + 1})
+			base = alt
+		}
+		query.WriteByte(base)
+		i++
+	}
+	return events
+}
+
+// indelLength draws an indel length from the model's length distribution.
+func indelLength(rng *rand.Rand, model Model) int {
+	if model.IndelLengthMean <= 0 {
+		return 1 + rng.Intn(model.MaxIndelLength)
+	}
+	if model.IndelLengthMean <= 1 {
+		return 1
+	}
+	// Geometric on {1, 2, ...}: P(L > n) = (1-p)^n with p = 1/mean.
+	length := 1 + int(math.Log(1-rng.Float64())/math.Log(1-1/model.IndelLengthMean))
+	if model.MaxIndelLength > 0 {
+		length = min(length, model.MaxIndelLength)
+	}
+	return length
+}
@@ -0,0 +1,92 @@
+package simulate
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func describe(res Result) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "ref_length %d query_length %d\n", len(res.Ref), len(res.Query))
+	for _, b := range res.Blocks {
+		strand := "+"
+		if b.Reverse {
+			strand = "-"
+		}
+		fmt.Fprintf(&sb, "block query [%d,%d) ref [%d,%d) %s\n", b.QueryStart, b.QueryEnd, b.RefStart, b.RefEnd, strand)
+	}
+	for _, ev := range res.Events {
+		fmt.Fprintf(&sb, "%s query [%d,%d) ref [%d,%d)\n", ev.Kind, ev.QueryStart, ev.QueryEnd, ev.RefStart, ev.RefEnd)
+	}
+	return sb.String()
+}
+
+func TestScenarios(t *testing.T) {
+	for _, sc := range Scenarios {
+		t.Run(sc.Name, func(t *testing.T) {
+			res := sc.Generate(1)
+			golden.AssertString(t, describe(res))
+
+			if len(res.Blocks) == 0 || res.Blocks[len(res.Blocks)-1].QueryEnd != len(res.Query) {
+				t.Fatalf("blocks do not tile the query")
+			}
+			for _, ev := range res.Events {
+				if ev.Kind != EventSNP {
+					continue
+				}
+				refBase := res.Ref[ev.RefStart]
+				for _, b := range res.Blocks {
+					if b.Reverse && ev.QueryStart >= b.QueryStart && ev.QueryStart < b.QueryEnd {
+						refBase = sequence.ReverseComplement(string(refBase))[0]
+					}
+				}
+				if res.Query[ev.QueryStart] == refBase {
+					t.Errorf("SNP at query %d keeps the reference base %c", ev.QueryStart, refBase)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	for _, sc := range Scenarios {
+		a, b := sc.Generate(7), sc.Generate(7)
+		if a.Query != b.Query || a.Ref != b.Ref || describe(a) != describe(b) {
+			t.Errorf("%s: two runs with the same seed differ", sc.Name)
+		}
+	}
+}
+
+func TestEventsRoundTrip(t *testing.T) {
+	res := Scenarios[len(Scenarios)-1].Generate(1)
+	var buf strings.Builder
+	if err := WriteEvents(&buf, res.Events); err != nil {
+		t.Fatal(err)
+	}
+	events, err := ReadEvents(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(events) != fmt.Sprint(res.Events) {
+		t.Errorf("events changed in a write/read round trip")
+	}
+	if _, err := ReadEvents(strings.NewReader("snp\t1\t2\t3\n")); err == nil {
+		t.Errorf("expected an error for a short line")
+	}
+}
+
+func TestTruthAlignments(t *testing.T) {
+	res := Scenarios[2].Generate(1) // Inversion
+	alns := res.Alignments()
+	if len(alns) != len(res.Blocks) {
+		t.Fatalf("%d alignments for %d blocks", len(alns), len(res.Blocks))
+	}
+	for i, aln := range alns {
+		if aln.Reverse != res.Blocks[i].Reverse || aln.Identity() < 0.98 {
+			t.Errorf("block %d: reverse=%v identity=%.3f", i, aln.Reverse, aln.Identity())
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package simulate
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Alignments returns the base-level alignment of every block of Segments on its true strand,
+// with the default parameters, for writing the truth as PAF or SAM.
+func (r Result) Alignments() []pairwise.Alignment {
+	var alns []pairwise.Alignment
+	p := config.Default()
+	scheme := scoring.FromParams(&p)
+	for _, b := range r.Blocks {
+		if b.QueryEnd <= b.QueryStart || b.RefEnd <= b.RefStart {
+			continue
+		}
+		qSpan := r.Query[b.QueryStart:b.QueryEnd]
+		if b.Reverse {
+			qSpan = sequence.ReverseComplement(qSpan)
+		}
+		aln := pairwise.Align(qSpan, r.Ref[b.RefStart:b.RefEnd], &p, scheme)
+		aln.Reverse = b.Reverse
+		alns = append(alns, aln)
+	}
+	return alns
+}
+
+// WriteEvents writes events as a TSV table with a header line. Coordinates are 0-based, half-open.
+func WriteEvents(w io.Writer, events []Event) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#kind\tref_start\tref_end\tquery_start\tquery_end")
+	for _, ev := range events {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", ev.Kind, ev.RefStart, ev.RefEnd, ev.QueryStart, ev.QueryEnd)
+	}
+	return bw.Flush()
+}
+
+// ReadEvents parses a table written by WriteEvents.
+func ReadEvents(r io.Reader) ([]Event, error) {
+	var events []Event
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("line %d: expected 5 tab-separated fields, got %d", line, len(fields))
+		}
+		ev := Event{Kind: fields[0]}
+		for i, dst := range []*int{&ev.RefStart, &ev.RefEnd, &ev.QueryStart, &ev.QueryEnd} {
+			v, err := strconv.Atoi(fields[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			*dst = v
+		}
+		events = append(events, ev)
+	}
+	return events, sc.Err()
+}
@@ -0,0 +1,105 @@
+package tune
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dimension is one searched parameter (or group of parameters moved together).
+// Set applies one of Values to parameters derived from the base preset.
+type Dimension struct {
+	Name   string
+	Values []int
+	Set    func(p *config.Params, v int)
+}
+
+// Space returns the default search space: k-values, stride, error budgets, merge gaps and
+// the minimum anchor length.
+func Space() []Dimension {
+	return []Dimension{
+		{
+			// Shifts every k-value list chosen by the GC and length buckets.
+			Name:   "k_shift",
+			Values: []int{-2, -1, 0, 1, 2},
+			Set: func(p *config.Params, v int) {
+				for _, ks := range []*[]int{&p.LowGCKValues, &p.MedGCKValues, &p.HighGCKValues, &p.VeryShortSeqKValues} {
+					*ks = shiftKValues(*ks, v)
+				}
+			},
+		},
+		{
+			Name:   "stride",
+			Values: []int{1, 2, 3},
+			Set: func(p *config.Params, v int) {
+				p.DefaultStride, p.VeryShortSeqStride = v, v
+			},
+		},
+		{
+			// Shifts the anchor error budgets of every bucket.
+			Name:   "error_shift",
+			Values: []int{-2, -1, 0, 1, 2},
+			Set: func(p *config.Params, v int) {
+				p.DefaultMaxErrors = max(0, p.DefaultMaxErrors+v)
+				p.LowGCMaxErrors = max(0, p.LowGCMaxErrors+v)
+				p.HighGCMaxErrors = max(0, p.HighGCMaxErrors+v)
+			},
+		},
+		{
+			Name:   "extend_max_errors",
+			Values: []int{4, 6, 8, 10},
+			Set:    func(p *config.Params, v int) { p.ExtendMaxErrors = v },
+		},
+		{
+			// Sets the adjacent merge gap; the final merge gap keeps its ratio to it.
+			Name:   "merge_gap",
+			Values: []int{16, 32, 48, 64},
+			Set: func(p *config.Params, v int) {
+				if p.AdjacentMergeMaxGap > 0 {
+					p.FinalMergeMaxGap = p.FinalMergeMaxGap * v / p.AdjacentMergeMaxGap
+				}
+				p.AdjacentMergeMaxGap = v
+			},
+		},
+		{
+			Name:   "min_match_length",
+			Values: []int{20, 24, 28, 32, 40},
+			Set:    func(p *config.Params, v int) { p.MinMatchLength = v },
+		},
+	}
+}
+
+// SelectDimensions returns the dimensions of Space with the given names, in that order.
+func SelectDimensions(names []string) ([]Dimension, error) {
+	byName := make(map[string]Dimension)
+	var all []string
+	for _, d := range Space() {
+		byName[d.Name] = d
+		all = append(all, d.Name)
+	}
+	var dims []Dimension
+	for _, name := range names {
+		d, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown tuning dimension %q (available: %s)", name, strings.Join(all, ", "))
+		}
+		dims = append(dims, d)
+	}
+	return dims, nil
+}
+
+// shiftKValues adds shift to every k, dropping values below 4 and duplicates.
+func shiftKValues(ks []int, shift int) []int {
+	seen := make(map[int]bool)
+	var out []int
+	for _, k := range ks {
+		k = max(4, k+shift)
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
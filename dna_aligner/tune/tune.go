@@ -0,0 +1,158 @@
+// Package tune searches the aligner parameters for the set that aligns a training set best.
+package tune
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/aligner"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/eval"
+	"DNA-Sequence-Alignments/dna_aligner/parallel"
+	"DNA-Sequence-Alignments/dna_aligner/report"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"fmt"
+	"math/rand"
+)
+
+// Example is one query/reference pair of the training set. With a Truth the pair is scored by
+// the F1 of eval.Evaluate; without one, by a self-consistency score: the fraction of the query
+// aligned times the identity of the aligned columns.
+type Example struct {
+	Name  string
+	Query string
+	Ref   string
+	Truth *eval.Mapping
+}
+
+// SimulatedExamples returns every simulate.Scenario generated with seeds 1..seeds, with its truth.
+func SimulatedExamples(seeds int) []Example {
+	var examples []Example
+	for seed := int64(1); seed <= int64(seeds); seed++ {
+		for _, sc := range simulate.Scenarios {
+			res := sc.Generate(seed)
+			truth := eval.MappingFromSegments("", len(res.Query), res.Segments(), res.Alignments())
+			examples = append(examples, Example{Name: fmt.Sprintf("%s/%d", sc.Name, seed), Query: res.Query, Ref: res.Ref, Truth: &truth})
+		}
+	}
+	return examples
+}
+
+// Score aligns ex with the parameters p and returns its score, between 0 and 1.
+func Score(ex Example, p *config.Params, threads int) float64 {
+	res := aligner.Align(ex.Query, ex.Ref, aligner.Options{Threads: threads, Params: p})
+	if ex.Truth == nil {
+		s := report.Summarize(len(ex.Query), len(ex.Ref), res.Segments)
+		return s.QueryCoverage * s.WeightedIdentity
+	}
+	pred := eval.MappingFromSegments("", len(ex.Query), res.Segments, res.Alignments)
+	return eval.Evaluate(pred, *ex.Truth, nil, eval.Options{}).F1
+}
+
+// Options controls Search.
+type Options struct {
+	Grid       bool        // Try every combination instead of random draws
+	Trials     int         // Random draws after the base parameters (ignored for a grid)
+	Seed       int64       // Seeds the random draws
+	Threads    int         // Examples aligned concurrently
+	Dimensions []Dimension // nil selects Space()
+
+	// Progress, when set, is called after every trial.
+	Progress func(t Trial)
+}
+
+// Trial is one evaluated parameter set.
+type Trial struct {
+	Index    int
+	Settings []string // "name=value" for every dimension; empty for the base parameters
+	Params   config.Params
+	Score    float64   // Mean over the examples
+	Scores   []float64 // Per example
+}
+
+// Search evaluates parameter sets derived from base on the examples and returns the best one.
+// The base itself is always trial 0, so the result never scores below it; ties keep the earlier
+// trial, and combinations that produce an already tried or invalid parameter set are skipped.
+func Search(base config.Params, examples []Example, opts Options) (Trial, error) {
+	if len(examples) == 0 {
+		return Trial{}, fmt.Errorf("no training examples")
+	}
+	if err := base.Validate(); err != nil {
+		return Trial{}, err
+	}
+	dims := opts.Dimensions
+	if dims == nil {
+		dims = Space()
+	}
+
+	var best Trial
+	trials := 0
+	tried := make(map[string]bool)
+	// try evaluates p unless it was tried before, and reports whether it did.
+	try := func(settings []string, p config.Params) bool {
+		key := p.String()
+		if tried[key] || p.Validate() != nil {
+			return false
+		}
+		tried[key] = true
+
+		t := Trial{Index: trials, Settings: settings, Params: p, Scores: make([]float64, len(examples))}
+		parallel.ForEach(len(examples), opts.Threads, func(i int) {
+			t.Scores[i] = Score(examples[i], &p, 1)
+		})
+		for _, s := range t.Scores {
+			t.Score += s
+		}
+		t.Score /= float64(len(examples))
+		if opts.Progress != nil {
+			opts.Progress(t)
+		}
+		if trials == 0 || t.Score > best.Score {
+			best = t
+		}
+		trials++
+		return true
+	}
+
+	try(nil, base)
+	if opts.Grid {
+		choice := make([]int, len(dims))
+		for {
+			try(candidate(base, dims, choice))
+			// Advance the mixed-radix counter over the dimensions.
+			i := 0
+			for ; i < len(dims); i++ {
+				choice[i]++
+				if choice[i] < len(dims[i].Values) {
+					break
+				}
+				choice[i] = 0
+			}
+			if i == len(dims) {
+				break
+			}
+		}
+		return best, nil
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for n, attempts := 0, 0; n < opts.Trials && attempts < 100*opts.Trials; attempts++ {
+		choice := make([]int, len(dims))
+		for i, d := range dims {
+			choice[i] = rng.Intn(len(d.Values))
+		}
+		if try(candidate(base, dims, choice)) {
+			n++
+		}
+	}
+	return best, nil
+}
+
+// candidate applies the chosen value of every dimension to a copy of base.
+func candidate(base config.Params, dims []Dimension, choice []int) ([]string, config.Params) {
+	p := base
+	settings := make([]string, len(dims))
+	for i, d := range dims {
+		v := d.Values[choice[i]]
+		d.Set(&p, v)
+		settings[i] = fmt.Sprintf("%s=%d", d.Name, v)
+	}
+	return settings, p
+}
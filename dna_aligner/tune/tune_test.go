@@ -0,0 +1,69 @@
+package tune
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSpaceYieldsValidParams(t *testing.T) {
+	base := config.Default()
+	for _, d := range Space() {
+		for _, v := range d.Values {
+			p := base
+			d.Set(&p, v)
+			if err := p.Validate(); err != nil {
+				t.Errorf("%s=%d: %v", d.Name, v, err)
+			}
+		}
+	}
+	if got := fmt.Sprint(shiftKValues([]int{5, 6, 7}, -2)); got != "[4 5]" {
+		t.Errorf("shiftKValues clamps to %s, want [4 5]", got)
+	}
+	if _, err := SelectDimensions([]string{"stride", "nope"}); err == nil {
+		t.Errorf("expected an error for an unknown dimension")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	common.LogWriter = io.Discard
+	examples := SimulatedExamples(1)[:2]
+	dims, err := SelectDimensions([]string{"k_shift", "min_match_length"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var log strings.Builder
+	opts := Options{Trials: 3, Seed: 1, Dimensions: dims, Progress: func(tr Trial) {
+		fmt.Fprintf(&log, "%d\t%.4f\t%s\t%.4f\n", tr.Index, tr.Score, strings.Join(tr.Settings, " "), tr.Scores)
+	}}
+	best, err := Search(config.Default(), examples, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(&log, "best %d %.4f\n", best.Index, best.Score)
+	golden.AssertString(t, log.String())
+
+	again, _ := Search(config.Default(), examples, Options{Trials: 3, Seed: 1, Dimensions: dims})
+	if again.Index != best.Index || again.Params.String() != best.Params.String() {
+		t.Errorf("two searches with the same seed disagree")
+	}
+	if _, err := Search(config.Default(), nil, opts); err == nil {
+		t.Errorf("expected an error without examples")
+	}
+}
+
+func TestGridSearchCoversEveryCombination(t *testing.T) {
+	common.LogWriter = io.Discard
+	dims, _ := SelectDimensions([]string{"stride", "extend_max_errors"})
+	n := 0
+	Search(config.Default(), SimulatedExamples(1)[:1], Options{Grid: true, Dimensions: dims, Progress: func(Trial) { n++ }})
+	// The base plus 3 strides x 4 budgets; no combination equals the base, whose strides differ.
+	if n != 13 {
+		t.Errorf("grid search ran %d trials, want 13", n)
+	}
+}
@@ -0,0 +1,199 @@
+package variants
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"sort"
+)
+
+// Variant is a small variant of the query relative to the reference, in VCF convention:
+// Pos is the 0-based reference position of the first Ref base, and indels carry the
+// preceding reference base as their anchor.
+type Variant struct {
+	Pos      int
+	Ref      string
+	Alt      string
+	QueryPos int  // 0-based query position of the event
+	Reverse  bool // Segment aligned to the reverse strand
+}
+
+// MaxIndelLength is the longest indel, in inserted or deleted bases, that passes the VCF filter.
+// Longer alignment gaps are more likely structural events than small variants.
+const MaxIndelLength = 50
+
+// Filter returns the VCF FILTER value of the variant: PASS, or LONG_INDEL for an indel of more
+// than MaxIndelLength bases.
+func (v Variant) Filter() string {
+	if n := len(v.Ref) - len(v.Alt); n > MaxIndelLength || -n > MaxIndelLength {
+		return "LONG_INDEL"
+	}
+	return "PASS"
+}
+
+// Type classifies the variant as SNP, MNP, INS, DEL or COMPLEX.
+func (v Variant) Type() string {
+	switch {
+	case len(v.Ref) == 1 && len(v.Alt) == 1:
+		return "SNP"
+	case len(v.Ref) == len(v.Alt):
+		return "MNP"
+	case len(v.Ref) == 1 && v.Alt[0] == v.Ref[0]:
+		return "INS"
+	case len(v.Alt) == 1 && v.Alt[0] == v.Ref[0]:
+		return "DEL"
+	}
+	return "COMPLEX"
+}
+
+// event is a variant in replacement form: ref[refStart:refEnd] is replaced by alt.
+// Insertions have refStart == refEnd, deletions have an empty alt.
+type event struct {
+	refStart int
+	refEnd   int
+	alt      string
+	queryPos int
+}
+
+// Call reports the SNPs and short indels in the base-level alignments of segments (alignments[i]
+// belongs to segments[i]), sorted by reference position. Indels are left-normalised and adjacent
+// events are merged. Fallback segments, placed without any supporting match, are not homologous
+// to the reference and are skipped.
+func Call(query, ref string, segments []common.Segment, alignments []pairwise.Alignment) []Variant {
+	var variants []Variant
+	for i, seg := range segments {
+		if seg.Source.IsFallback() {
+			continue
+		}
+		aln := alignments[i]
+		qSpan := query[seg.QueryStart : seg.QueryEnd+1]
+		if aln.Reverse {
+			qSpan = sequence.ReverseComplement(qSpan)
+		}
+
+		events := collectEvents(qSpan, ref, seg.RefStart, aln.Ops)
+		events = normalizeEvents(ref, seg.RefStart, events)
+		events = mergeAdjacentEvents(events)
+
+		for _, ev := range events {
+			v := toVariant(ref, ev)
+			if v.Ref == "" {
+				continue // Insertion before the first reference base has no anchor
+			}
+			v.QueryPos = seg.QueryStart + ev.queryPos
+			if aln.Reverse {
+				v.QueryPos = seg.QueryEnd - ev.queryPos
+			}
+			v.Reverse = aln.Reverse
+			variants = append(variants, v)
+		}
+	}
+
+	sort.SliceStable(variants, func(i, j int) bool {
+		if variants[i].Pos != variants[j].Pos {
+			return variants[i].Pos < variants[j].Pos
+		}
+		if variants[i].Ref != variants[j].Ref {
+			return variants[i].Ref < variants[j].Ref
+		}
+		return variants[i].Alt < variants[j].Alt
+	})
+
+	// Overlapping segments can report the same event more than once.
+	var unique []Variant
+	for _, v := range variants {
+		if n := len(unique); n > 0 && unique[n-1].Pos == v.Pos && unique[n-1].Ref == v.Ref && unique[n-1].Alt == v.Alt {
+			continue
+		}
+		unique = append(unique, v)
+	}
+	return unique
+}
+
+// collectEvents walks the alignment ops and returns one event per mismatch or gap.
+// qSpan is the query span in reference orientation; refStart is the reference offset of the alignment.
+func collectEvents(qSpan, ref string, refStart int, ops []pairwise.Op) []event {
+	var events []event
+	qPos, rPos := 0, refStart
+	for _, op := range ops {
+		switch op.Kind {
+		case pairwise.OpMatch:
+			qPos += op.Len
+			rPos += op.Len
+		case pairwise.OpMismatch:
+			for k := 0; k < op.Len; k++ {
+				if qSpan[qPos+k] != 'N' && ref[rPos+k] != 'N' {
+					events = append(events, event{refStart: rPos + k, refEnd: rPos + k + 1, alt: qSpan[qPos+k : qPos+k+1], queryPos: qPos + k})
+				}
+			}
+			qPos += op.Len
+			rPos += op.Len
+		case pairwise.OpInsertion:
+			events = append(events, event{refStart: rPos, refEnd: rPos, alt: qSpan[qPos : qPos+op.Len], queryPos: qPos})
+			qPos += op.Len
+		case pairwise.OpDeletion:
+			events = append(events, event{refStart: rPos, refEnd: rPos + op.Len, queryPos: qPos})
+			rPos += op.Len
+		}
+	}
+	return events
+}
+
+// normalizeEvents shifts indels as far left as the reference allows without crossing
+// the previous event or the start of the aligned region.
+func normalizeEvents(ref string, lowerBound int, events []event) []event {
+	for i := range events {
+		ev := &events[i]
+		bound := lowerBound
+		if i > 0 {
+			bound = events[i-1].refEnd
+		}
+		switch {
+		case ev.refStart == ev.refEnd: // Insertion
+			for ev.refStart > bound && ref[ev.refStart-1] == ev.alt[len(ev.alt)-1] {
+				ev.alt = ref[ev.refStart-1:ev.refStart] + ev.alt[:len(ev.alt)-1]
+				ev.refStart--
+				ev.refEnd--
+				ev.queryPos--
+			}
+		case ev.alt == "": // Deletion
+			for ev.refStart > bound && ref[ev.refStart-1] == ref[ev.refEnd-1] {
+				ev.refStart--
+				ev.refEnd--
+				ev.queryPos--
+			}
+		}
+	}
+	return events
+}
+
+// mergeAdjacentEvents combines events that touch on the reference into a single record,
+// so a run of substitutions becomes an MNP and a substitution next to an indel a complex event.
+func mergeAdjacentEvents(events []event) []event {
+	var merged []event
+	for _, ev := range events {
+		if n := len(merged); n > 0 && ev.refStart <= merged[n-1].refEnd {
+			prev := &merged[n-1]
+			prev.alt += ev.alt
+			if ev.refEnd > prev.refEnd {
+				prev.refEnd = ev.refEnd
+			}
+			continue
+		}
+		merged = append(merged, ev)
+	}
+	return merged
+}
+
+// toVariant converts an event to VCF form, adding the preceding reference base to indels.
+func toVariant(ref string, ev event) Variant {
+	refAllele := ref[ev.refStart:ev.refEnd]
+	if len(refAllele) > 0 && len(ev.alt) > 0 {
+		return Variant{Pos: ev.refStart, Ref: refAllele, Alt: ev.alt}
+	}
+	if ev.refStart == 0 {
+		return Variant{}
+	}
+	anchor := ref[ev.refStart-1 : ev.refStart]
+	return Variant{Pos: ev.refStart - 1, Ref: anchor + refAllele, Alt: anchor + ev.alt}
+}
@@ -0,0 +1,71 @@
+package variants
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/common"
+	"DNA-Sequence-Alignments/dna_aligner/config"
+	"DNA-Sequence-Alignments/dna_aligner/pairwise"
+	"DNA-Sequence-Alignments/dna_aligner/scoring"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// TestCallVCF calls a SNP, an insertion and a deletion placed in a random sequence and writes
+// them as VCF.
+func TestCallVCF(t *testing.T) {
+	ref := simulate.RandomSequence(rand.New(rand.NewSource(4)), 300, 0.5)
+	snp := map[byte]string{'A': "C", 'C': "G", 'G': "T", 'T': "A"}[ref[60]]
+	query := ref[:60] + snp + ref[61:150] + "TTAGC" + ref[150:220] + ref[223:]
+	p := config.Default()
+	segs := []common.Segment{{QueryStart: 0, QueryEnd: len(query) - 1, RefStart: 0, RefEnd: len(ref) - 1}}
+	calls := Call(query, ref, segs, []pairwise.Alignment{pairwise.AlignSegment(query, ref, &p, scoring.FromParams(&p), segs[0])})
+
+	var buf bytes.Buffer
+	header := VCFHeader{RefName: "ref", RefLength: len(ref), SampleName: "query", Source: "test"}
+	if err := WriteVCF(&buf, header, calls); err != nil {
+		t.Fatal(err)
+	}
+	golden.Assert(t, buf.Bytes())
+}
+
+// TestCallSkipsFallbackSegments checks that a fallback segment, placed on an unrelated part of
+// the reference, yields no variants, and that a long deletion is called but filtered.
+func TestCallSkipsFallbackSegments(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	ref := simulate.RandomSequence(rng, 400, 0.5)
+	query := simulate.RandomSequence(rng, 100, 0.5)
+	p := config.Default()
+	scheme := scoring.FromParams(&p)
+	for _, source := range []common.SegmentSource{common.SourceSampledFallback, common.SourceModuloFallback} {
+		segs := []common.Segment{{QueryStart: 0, QueryEnd: 99, RefStart: 200, RefEnd: 299, Source: source}}
+		alns := []pairwise.Alignment{pairwise.AlignSegment(query, ref, &p, scheme, segs[0])}
+		if calls := Call(query, ref, segs, alns); len(calls) != 0 {
+			t.Errorf("%s segment: %d variants, want 0", source, len(calls))
+		}
+	}
+
+	query = ref[:150] + ref[150+MaxIndelLength+10:]
+	segs := []common.Segment{{QueryStart: 0, QueryEnd: len(query) - 1, RefStart: 0, RefEnd: len(ref) - 1}}
+	calls := Call(query, ref, segs, []pairwise.Alignment{pairwise.AlignSegment(query, ref, &p, scheme, segs[0])})
+	if len(calls) != 1 || calls[0].Type() != "DEL" || calls[0].Filter() != "LONG_INDEL" {
+		t.Errorf("long deletion: got %+v, want one DEL filtered as LONG_INDEL", calls)
+	}
+}
+
+// TestCallFindsSimulatedSNPs checks that every isolated simulated SNP is called at its true position.
+func TestCallFindsSimulatedSNPs(t *testing.T) {
+	res := simulate.Scenarios[0].Generate(3)
+	called := make(map[int]bool)
+	for _, v := range Call(res.Query, res.Ref, res.Segments(), res.Alignments()) {
+		if v.Type() == "SNP" {
+			called[v.Pos] = true
+		}
+	}
+	for _, ev := range res.Events {
+		if ev.Kind == simulate.EventSNP && !called[ev.RefStart] {
+			t.Errorf("SNP at reference position %d was not called", ev.RefStart)
+		}
+	}
+}
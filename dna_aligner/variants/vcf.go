@@ -0,0 +1,47 @@
+package variants
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// VCFHeader describes the reference contig and the sample column of a VCF file.
+type VCFHeader struct {
+	RefName    string
+	RefLength  int
+	SampleName string
+	Source     string
+	Meta       []string // Extra meta-information lines, without the leading "##"
+}
+
+// WriteVCF writes the variants as a single-sample VCF 4.2 file. The query is reported
+// as a haploid sample carrying every alternate allele; the FILTER column is Variant.Filter.
+func WriteVCF(w io.Writer, header VCFHeader, variants []Variant) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "##fileformat=VCFv4.2")
+	if header.Source != "" {
+		fmt.Fprintf(bw, "##source=%s\n", header.Source)
+	}
+	for _, m := range header.Meta {
+		fmt.Fprintf(bw, "##%s\n", m)
+	}
+	fmt.Fprintf(bw, "##contig=<ID=%s,length=%d>\n", header.RefName, header.RefLength)
+	fmt.Fprintf(bw, "##FILTER=<ID=LONG_INDEL,Description=\"Indel longer than %d bases\">\n", MaxIndelLength)
+	fmt.Fprintln(bw, `##INFO=<ID=TYPE,Number=1,Type=String,Description="Variant type: SNP, MNP, INS, DEL or COMPLEX">`)
+	fmt.Fprintln(bw, `##INFO=<ID=QPOS,Number=1,Type=Integer,Description="1-based query position of the event">`)
+	fmt.Fprintln(bw, `##INFO=<ID=STRAND,Number=1,Type=String,Description="Strand of the query segment carrying the event">`)
+	fmt.Fprintln(bw, `##FORMAT=<ID=GT,Number=1,Type=String,Description="Genotype">`)
+	fmt.Fprintf(bw, "#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\t%s\n", header.SampleName)
+
+	for _, v := range variants {
+		strand := "+"
+		if v.Reverse {
+			strand = "-"
+		}
+		fmt.Fprintf(bw, "%s\t%d\t.\t%s\t%s\t.\t%s\tTYPE=%s;QPOS=%d;STRAND=%s\tGT\t1\n",
+			header.RefName, v.Pos+1, v.Ref, v.Alt, v.Filter(), v.Type(), v.QueryPos+1, strand)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,99 @@
+package dups
+
+// alignCopy aligns the whole unit against the query starting at start, letting the copy in
+// the query be up to maxEdits bases shorter or longer than the unit. It returns the copy with
+// the fewest edits (mismatches, insertions and deletions), preferring the length closest to
+// the unit's, and reports whether that copy has at most maxEdits edits.
+func alignCopy(unit, query string, start, maxEdits int) (Copy, bool) {
+	m := len(unit)
+	limit := min(len(query)-start, m+maxEdits)
+	if limit < m-maxEdits {
+		return Copy{}, false
+	}
+	last := editRows(unit, query[start:start+limit], func(int, []int) {})
+
+	best, bestEdits := -1, maxEdits+1
+	for j := max(0, m-maxEdits); j <= limit; j++ {
+		if last[j] < bestEdits || (last[j] == bestEdits && best >= 0 && abs(j-m) < abs(best-m)) {
+			best, bestEdits = j, last[j]
+		}
+	}
+	if best < 0 {
+		return Copy{}, false
+	}
+	return Copy{
+		QueryStart: start,
+		QueryEnd:   start + best,
+		Edits:      bestEdits,
+		Identity:   1 - float64(bestEdits)/float64(max(m, best)),
+	}, true
+}
+
+// alignPartialCopy finds the longest proper prefix of the unit, at least minLength bases long,
+// that the query starting at start holds with few edits: a prefix of length i may have
+// maxEdits*i/len(unit) edits, and the copy must end on a matching base.
+func alignPartialCopy(unit, query string, start, maxEdits, minLength int) (Copy, bool) {
+	m := len(unit)
+	limit := min(len(query)-start, m+maxEdits)
+	if limit <= 0 {
+		return Copy{}, false
+	}
+	text := query[start : start+limit]
+
+	var best Copy
+	bestPrefix := 0
+	editRows(unit, text, func(i int, row []int) {
+		if i < minLength || i >= m {
+			return
+		}
+		allowed := maxEdits * i / m
+		for j := max(1, i-allowed); j <= min(limit, i+allowed); j++ {
+			if row[j] > allowed || text[j-1] != unit[i-1] {
+				continue
+			}
+			if i > bestPrefix || row[j] < best.Edits {
+				best = Copy{
+					QueryStart: start,
+					QueryEnd:   start + j,
+					Edits:      row[j],
+					Identity:   1 - float64(row[j])/float64(max(i, j)),
+					Partial:    true,
+				}
+				bestPrefix = i
+			}
+		}
+	})
+	return best, bestPrefix > 0
+}
+
+// editRows computes the edit distances between every prefix of unit and every prefix of text,
+// calling visit with each row i (distances of unit[:i] against text[:j] for every j), and
+// returns the last row.
+func editRows(unit, text string, visit func(i int, row []int)) []int {
+	prev := make([]int, len(text)+1)
+	cur := make([]int, len(text)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	visit(0, prev)
+	for i := 1; i <= len(unit); i++ {
+		cur[0] = i
+		for j := 1; j <= len(text); j++ {
+			cost := 1
+			if unit[i-1] == text[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j-1]+cost, prev[j]+1, cur[j-1]+1)
+		}
+		visit(i, cur)
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
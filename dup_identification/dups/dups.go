@@ -0,0 +1,233 @@
+// Package dups finds duplicated reference segments in a query sequence: tandem repeats of
+// reference units, exact or approximate, and interspersed duplications. It is built on a
+// suffix automaton (SAM) of the reference and of its reverse complement.
+package dups
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"errors"
+	"strings"
+)
+
+// Duplicate is a run of Count adjacent full copies of a reference unit in the query, possibly
+// followed by a partial copy (see Copy).
+// Ends are exclusive. RefStart/RefEnd give the forward-strand span of the reference
+// occurrence matched by the suffix automaton walk; for an inverted unit this is the span
+// whose reverse complement equals the query copy. RefOccurrences lists the forward-strand
+// start of every occurrence of the unit on the same strand, in increasing order.
+type Duplicate struct {
+	QueryStart     int    `json:"query_start"`
+	QueryEnd       int    `json:"query_end"`
+	RefStart       int    `json:"ref_start"`
+	RefEnd         int    `json:"ref_end"`
+	Length         int    `json:"length"`
+	Count          int    `json:"count"`
+	IsInverted     bool   `json:"inverted"`
+	RefOccurrences []int  `json:"ref_occurrences"`
+	Copies         []Copy `json:"copies"`
+}
+
+// Copy is one copy of a duplicated unit in the query. Exact copies have identity 1; in
+// approximate mode a copy may differ from the unit by up to Options.MaxEdits edits. The last
+// copy may be a partial copy covering only a prefix of the unit, and the first copy of a
+// repeat array a partial copy covering only a suffix (see mergeRepeatArrays).
+type Copy struct {
+	QueryStart int     `json:"query_start"`
+	QueryEnd   int     `json:"query_end"`
+	Edits      int     `json:"edits"`
+	Identity   float64 `json:"identity"` // 1 - Edits / length of the longer of the copy and the unit (prefix)
+	Partial    bool    `json:"partial,omitempty"`
+}
+
+// Options controls Analyze. The zero value counts exact copies of units of at least
+// DefaultMinUnitLength bases.
+type Options struct {
+	// MaxEdits is the number of mismatches and indels tolerated per copy after the first,
+	// which must match the reference exactly. 0 counts exact copies only. Units shorter than
+	// 4*MaxEdits tolerate a quarter of their length, so short units do not absorb random sequence.
+	MaxEdits int
+	// MinPartial is the shortest trailing partial copy reported in approximate mode;
+	// 0 selects half the unit length.
+	MinPartial int
+	// MinUnitLength is the shortest repeat unit reported; query positions whose longest
+	// reference match is shorter are skipped. It also bounds the unit that period detection
+	// reduces a periodic match to. 0 selects DefaultMinUnitLength.
+	MinUnitLength int
+	// SkipOccurrences leaves Duplicate.RefOccurrences nil, for callers that only need the runs.
+	SkipOccurrences bool
+}
+
+// DefaultMinUnitLength is the shortest repeat unit reported when Options.MinUnitLength is 0.
+// Shorter units are mostly chance matches, each occurring throughout the reference.
+const DefaultMinUnitLength = 3
+
+func (o Options) editBudget(unitLength int) int {
+	return min(o.MaxEdits, unitLength/4)
+}
+
+func (o Options) minPartial(unitLength int) int {
+	if o.MinPartial > 0 {
+		return o.MinPartial
+	}
+	return max(1, unitLength/2)
+}
+
+// Analyze splits the query into runs of copies of reference units, in query order. Each query
+// position starts the longest reference match (on either strand) available there; the match,
+// or its primitive period when it is itself a repeat, is the unit of a run that extends over
+// the following copies. Sequences are upper-cased before the analysis.
+func Analyze(query, ref string, opts Options) ([]Duplicate, error) {
+	if query == "" || ref == "" {
+		return nil, errors.New("query and reference sequences must not be empty")
+	}
+	if opts.MaxEdits < 0 || opts.MinPartial < 0 || opts.MinUnitLength < 0 {
+		return nil, errors.New("MaxEdits, MinPartial and MinUnitLength must not be negative")
+	}
+	if opts.MinUnitLength == 0 {
+		opts.MinUnitLength = DefaultMinUnitLength
+	}
+	return analyze(strings.ToUpper(query), strings.ToUpper(ref), opts), nil
+}
+
+func analyze(query, ref string, opts Options) []Duplicate {
+
+	// 生成反向互补序列
+	invRef := sequence.ReverseComplement(ref)
+
+	// 构建正向和反向引用序列的后缀自动机
+	refSAM := BuildSAMByString(ref)
+	invRefSAM := BuildSAMByString(invRef)
+
+	// 存储结果
+	var duplicates []Duplicate
+
+	// 预计算查询序列每个位置的最优匹配
+	type matchInfo struct {
+		maxLength int
+		isInv     bool
+		refPos    int // 匹配在正向参考序列上的起始位置
+	}
+
+	// 为查询序列的每个位置计算最大匹配长度和匹配类型
+	// 一次遍历得到所有位置的正向和反向匹配统计量
+	forwardStats := refSAM.MatchingStatistics(query)
+	reverseStats := invRefSAM.MatchingStatistics(query)
+	matchData := make([]matchInfo, len(query))
+	for pos := 0; pos < len(query); pos++ {
+		// 计算正向和反向的最大匹配长度
+		forwardLen, forwardPos := forwardStats[pos].Length, forwardStats[pos].RefEnd-forwardStats[pos].Length
+		reverseLen, reversePos := reverseStats[pos].Length, reverseStats[pos].RefEnd-reverseStats[pos].Length
+
+		// 决定使用哪种匹配
+		isInverted := reverseLen > forwardLen || (reverseLen == forwardLen && reverseLen > 0)
+		bestLen, refPos := forwardLen, forwardPos
+		if isInverted {
+			// 反向互补序列上的 [p, p+len) 对应正向参考序列上的 [n-p-len, n-p)
+			bestLen, refPos = reverseLen, len(ref)-reversePos-reverseLen
+		}
+
+		matchData[pos] = matchInfo{
+			maxLength: bestLen,
+			isInv:     isInverted,
+			refPos:    refPos,
+		}
+	}
+
+	// 处理查询序列，寻找所有可能的重复
+	position := 0
+	for position < len(query) {
+		currentMatch := matchData[position]
+
+		// 如果当前位置没有匹配或匹配过短，移动到下一个位置
+		if currentMatch.maxLength == 0 || currentMatch.maxLength < opts.MinUnitLength {
+			position++
+			continue
+		}
+
+		// 获取重复单元的长度和类型
+		unitLength := currentMatch.maxLength
+		unitInverted := currentMatch.isInv
+		refPosition := currentMatch.refPos
+
+		// 匹配本身由更短的单元重复构成时，以最短周期（不短于最小单元长度）作为重复单元
+		period := repeatPeriod(query[position:position+unitLength], opts.MinUnitLength)
+		periodic := period < unitLength
+		if periodic && unitInverted {
+			// 反向单元对应参考序列匹配区间的末端
+			refPosition += unitLength - period
+		}
+
+		// 提取重复单元
+		repeatUnit := query[position : position+period]
+
+		// 计算连续重复次数
+		repeatCount := 1
+		nextStart := position + period
+		copies := []Copy{{QueryStart: position, QueryEnd: nextStart, Identity: 1}}
+		unitLength = period
+
+		if budget := opts.editBudget(unitLength); budget > 0 {
+			// 近似模式：每个拷贝允许少量错配和插入缺失，末尾允许不完整的拷贝
+			for {
+				c, ok := alignCopy(repeatUnit, query, nextStart, budget)
+				if !ok {
+					break
+				}
+				copies = append(copies, c)
+				repeatCount++
+				nextStart = c.QueryEnd
+			}
+			if c, ok := alignPartialCopy(repeatUnit, query, nextStart, budget, opts.minPartial(unitLength)); ok {
+				copies = append(copies, c)
+				nextStart = c.QueryEnd
+			}
+		} else {
+			for nextStart+unitLength <= len(query) {
+				// 检查下一个单元是否符合重复条件（周期单元只比较序列）
+				if query[nextStart:nextStart+unitLength] != repeatUnit ||
+					!periodic && (matchData[nextStart].maxLength < unitLength ||
+						matchData[nextStart].isInv != unitInverted) {
+					break
+				}
+				copies = append(copies, Copy{QueryStart: nextStart, QueryEnd: nextStart + unitLength, Identity: 1})
+				repeatCount++
+				nextStart += unitLength
+			}
+			// 周期重复阵列末尾不完整的拷贝
+			if n := commonPrefix(query[nextStart:], repeatUnit); periodic && n > 0 {
+				copies = append(copies, Copy{QueryStart: nextStart, QueryEnd: nextStart + n, Identity: 1, Partial: true})
+				nextStart += n
+			}
+		}
+
+		// 记录结果：匹配到的位置以及参考序列中的所有出现位置（按重复单元在正向参考序列上的形式查找）
+		var refOccurrences []int
+		if !opts.SkipOccurrences {
+			refUnit := repeatUnit
+			if unitInverted {
+				refUnit = sequence.ReverseComplement(repeatUnit)
+			}
+			refOccurrences = refSAM.Occurrences(refUnit)
+		}
+		duplicates = append(duplicates, Duplicate{
+			QueryStart:     position,
+			QueryEnd:       nextStart,
+			RefStart:       refPosition,
+			RefEnd:         refPosition + unitLength,
+			Length:         unitLength,
+			Count:          repeatCount,
+			IsInverted:     unitInverted,
+			RefOccurrences: refOccurrences,
+			Copies:         copies,
+		})
+
+		// 跳到下一个未处理的位置
+		position = nextStart
+	}
+
+	occurrenceSAM := refSAM
+	if opts.SkipOccurrences {
+		occurrenceSAM = nil
+	}
+	return mergeRepeatArrays(query, ref, occurrenceSAM, duplicates, opts.MinUnitLength)
+}
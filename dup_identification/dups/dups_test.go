@@ -0,0 +1,232 @@
+package dups
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func formatDuplicates(dups []Duplicate) string {
+	var sb strings.Builder
+	for _, d := range dups {
+		fmt.Fprintf(&sb, "query=%d-%d ref=%d-%d len=%d count=%d inverted=%v occurrences=%v\n",
+			d.QueryStart, d.QueryEnd, d.RefStart, d.RefEnd, d.Length, d.Count, d.IsInverted, d.RefOccurrences)
+	}
+	return sb.String()
+}
+
+func readSequence(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// mustAnalyze runs Analyze and fails the test on an error.
+func mustAnalyze(t *testing.T, query, ref string, opts Options) []Duplicate {
+	t.Helper()
+	dups, err := Analyze(query, ref, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dups
+}
+
+func TestAnalyzeBundledData(t *testing.T) {
+	golden.AssertString(t, formatDuplicates(mustAnalyze(t, readSequence(t, "../data/query.txt"), readSequence(t, "../data/ref.txt"), Options{})))
+}
+
+// TestAnalyzeTandemRepeats builds queries with known tandem copies of reference units.
+func TestAnalyzeTandemRepeats(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ref := simulate.RandomSequence(rng, 400, 0.5)
+	unit := ref[100:150]
+	cases := []struct {
+		name  string
+		query string
+	}{
+		{"forward, two extra copies", ref[:150] + strings.Repeat(unit, 2) + ref[150:]},
+		{"inverted, two extra copies", ref[:150] + strings.Repeat(sequence.ReverseComplement(unit), 2) + ref[150:]},
+		{"no duplication", ref},
+	}
+	var sb strings.Builder
+	for _, c := range cases {
+		fmt.Fprintf(&sb, "== %s\n%s", c.name, formatDuplicates(mustAnalyze(t, c.query, ref, Options{})))
+	}
+	golden.AssertString(t, sb.String())
+}
+
+// TestAnalyzeAssemblyGaps checks that runs of N and ambiguity codes, in the query and in the
+// reference, are not reported as duplicated units.
+func TestAnalyzeAssemblyGaps(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	ref := simulate.RandomSequence(rng, 300, 0.5) + strings.Repeat("N", 100) + "RYRYRYRY" + simulate.RandomSequence(rng, 300, 0.5)
+	query := ref[:200] + strings.Repeat("N", 250) + strings.Repeat("RY", 20) + ref[450:]
+	for _, d := range mustAnalyze(t, query, ref, Options{}) {
+		for _, c := range d.Copies {
+			if unit := query[c.QueryStart:c.QueryEnd]; strings.ContainsAny(unit, "NRY") {
+				t.Errorf("copy %d-%d of %+v holds an ambiguous base: %q", c.QueryStart, c.QueryEnd, d, unit)
+			}
+		}
+	}
+}
+
+func TestSAMFindMaxMatch(t *testing.T) {
+	sam := BuildSAMByString("ACGTACGGT")
+	var sb strings.Builder
+	for _, q := range []string{"ACGG", "CGTACGGTA", "TTT", "GTAC"} {
+		fmt.Fprintf(&sb, "%s %d\n", q, sam.FindMaxMatch(q, 0))
+	}
+	golden.AssertString(t, sb.String())
+}
+
+// tilesSpan reports whether the copies of d are back to back over its query span, with Count
+// full copies and partial copies only at either end.
+func tilesSpan(d Duplicate) bool {
+	pos, full := d.QueryStart, 0
+	for i, c := range d.Copies {
+		if c.QueryStart != pos || c.Partial && i != 0 && i != len(d.Copies)-1 {
+			return false
+		}
+		if !c.Partial {
+			full++
+		}
+		pos = c.QueryEnd
+	}
+	return pos == d.QueryEnd && full == d.Count
+}
+
+// TestDuplicateCoordinates checks that every record's spans hold the reported copies, on the
+// reverse strand for inverted units, and that repeated reference units list every occurrence.
+func TestDuplicateCoordinates(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	unit := simulate.RandomSequence(rng, 40, 0.5)
+	ref := simulate.RandomSequence(rng, 100, 0.5) + unit + simulate.RandomSequence(rng, 100, 0.5) + unit + simulate.RandomSequence(rng, 100, 0.5)
+	queries := map[string]string{
+		"forward":  ref[:100] + unit + unit + ref[100:],
+		"inverted": ref[:100] + strings.Repeat(sequence.ReverseComplement(unit), 3) + ref[100:],
+	}
+	for name, query := range queries {
+		repeated := false
+		for _, d := range mustAnalyze(t, query, ref, Options{}) {
+			if d.RefEnd != d.RefStart+d.Length || !tilesSpan(d) {
+				t.Fatalf("%s: inconsistent spans in %+v", name, d)
+			}
+			first := firstFullCopy(d)
+			queryCopy := query[first : first+d.Length]
+			if d.IsInverted {
+				queryCopy = sequence.ReverseComplement(queryCopy)
+			}
+			if ref[d.RefStart:d.RefEnd] != queryCopy {
+				t.Errorf("%s: reference span %d-%d does not hold the copy at %d", name, d.RefStart, d.RefEnd, d.QueryStart)
+			}
+			if !slices.Contains(d.RefOccurrences, d.RefStart) {
+				t.Errorf("%s: occurrences %v miss the matched start %d", name, d.RefOccurrences, d.RefStart)
+			}
+			if d.Count >= 2 && d.Length == len(unit) {
+				repeated = true
+				if want := []int{100, 240}; !slices.Equal(d.RefOccurrences, want) {
+					t.Errorf("%s: occurrences %v, want %v", name, d.RefOccurrences, want)
+				}
+			}
+		}
+		if !repeated {
+			t.Errorf("%s: no record for the repeated unit", name)
+		}
+	}
+}
+
+func formatCopies(dups []Duplicate) string {
+	var sb strings.Builder
+	for _, d := range dups {
+		fmt.Fprintf(&sb, "query=%d-%d ref=%d-%d len=%d count=%d inverted=%v\n", d.QueryStart, d.QueryEnd, d.RefStart, d.RefEnd, d.Length, d.Count, d.IsInverted)
+		for _, c := range d.Copies {
+			fmt.Fprintf(&sb, "  copy %d-%d edits=%d identity=%.3f partial=%v\n", c.QueryStart, c.QueryEnd, c.Edits, c.Identity, c.Partial)
+		}
+	}
+	return sb.String()
+}
+
+// TestAnalyzeApproximateTandemRepeats inserts mutated copies of a unit, followed by a partial
+// copy, and compares exact and approximate counting.
+func TestAnalyzeApproximateTandemRepeats(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	ref := simulate.RandomSequence(rng, 400, 0.5)
+	unit := ref[100:160]
+	mutate := func(s string, pos int, insert string, del int) string {
+		return s[:pos] + insert + s[pos+del:]
+	}
+	sub := map[byte]string{'A': "C", 'C': "G", 'G': "T", 'T': "A"}
+	// tandem is an exact copy of unit followed by mutated copies and a partial copy.
+	tandem := func(unit string) string {
+		return unit +
+			mutate(unit, 20, sub[unit[20]], 1) + // substitution
+			mutate(unit, 35, "", 1) + // deletion
+			mutate(unit, 10, "G", 0) + // insertion
+			unit[:40] // partial copy
+	}
+	cases := []struct {
+		name  string
+		query string
+	}{
+		{"forward", ref[:160] + tandem(unit) + ref[160:]},
+		{"inverted", ref[:160] + tandem(sequence.ReverseComplement(unit)) + ref[160:]},
+	}
+	var sb strings.Builder
+	for _, c := range cases {
+		for _, opts := range []Options{{}, {MaxEdits: 2}} {
+			fmt.Fprintf(&sb, "== %s max-edits=%d\n%s", c.name, opts.MaxEdits, formatCopies(mustAnalyze(t, c.query, ref, opts)))
+		}
+	}
+	golden.AssertString(t, sb.String())
+}
+
+// TestMatchingStatistics checks the one-pass matching statistics against a walk from the root
+// at every query position.
+func TestMatchingStatistics(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	for trial := 0; trial < 20; trial++ {
+		ref := simulate.RandomSequence(rng, 300, 0.5)
+		query := ref[50:120] + simulate.RandomSequence(rng, 30, 0.5) + sequence.ReverseComplement(ref[200:260]) + ref[10:40]
+		sam := BuildSAMByString(ref)
+		for i, m := range sam.MatchingStatistics(query) {
+			if want := sam.FindMaxMatch(query, i); m.Length != want {
+				t.Fatalf("trial %d, position %d: length %d, want %d", trial, i, m.Length, want)
+			}
+			if m.Length > 0 && ref[m.RefEnd-m.Length:m.RefEnd] != query[i:i+m.Length] {
+				t.Fatalf("trial %d, position %d: reference end %d does not end the match", trial, i, m.RefEnd)
+			}
+		}
+	}
+	if stats := BuildSAMByString("ACGT").MatchingStatistics(""); len(stats) != 0 {
+		t.Errorf("empty query: %v", stats)
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	for _, c := range []struct {
+		query, ref string
+		opts       Options
+	}{
+		{"", "ACGT", Options{}},
+		{"ACGT", "", Options{}},
+		{"ACGT", "ACGT", Options{MaxEdits: -1}},
+		{"ACGT", "ACGT", Options{MinUnitLength: -1}},
+	} {
+		if _, err := Analyze(c.query, c.ref, c.opts); err == nil {
+			t.Errorf("Analyze(%q, %q, %+v) returned no error", c.query, c.ref, c.opts)
+		}
+	}
+	lower := mustAnalyze(t, "acgtacgt", "ACGTT", Options{})
+	if len(lower) != 1 || lower[0].Count != 2 {
+		t.Errorf("lower-case query: %+v", lower)
+	}
+}
@@ -0,0 +1,143 @@
+package dups
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Locus is one copy of a reference region in the query. Ends are exclusive.
+type Locus struct {
+	QueryStart int  `json:"query_start"`
+	QueryEnd   int  `json:"query_end"`
+	IsInverted bool `json:"inverted"`
+}
+
+// Interspersed is a reference region that the query holds several times: either at separated
+// positions (Separated: two consecutive copies are apart by at least the region length, so
+// they are not part of one tandem array) or more often than the reference itself
+// (QueryCopies > RefCopies).
+// RefCopies counts the occurrences of the region in the reference on both strands.
+type Interspersed struct {
+	RefStart    int     `json:"ref_start"`
+	RefEnd      int     `json:"ref_end"`
+	QueryCopies int     `json:"query_copies"`
+	RefCopies   int     `json:"ref_copies"`
+	Separated   bool    `json:"separated"`
+	Loci        []Locus `json:"loci"`
+}
+
+// placedCopy is a copy of a duplicated unit with the reference span it stands for.
+type placedCopy struct {
+	Copy
+	refStart, refEnd int
+	inverted         bool
+}
+
+// placeCopies lists every copy of the duplicates with its forward-strand reference span.
+// A trailing partial copy covers a prefix of the unit: the start of its reference span for a
+// forward unit and the end for an inverted one. A leading partial copy covers a suffix.
+func placeCopies(dups []Duplicate) []placedCopy {
+	var placed []placedCopy
+	for _, d := range dups {
+		for i, c := range d.Copies {
+			pc := placedCopy{Copy: c, refStart: d.RefStart, refEnd: d.RefEnd, inverted: d.IsInverted}
+			if c.Partial {
+				n := min(c.QueryEnd-c.QueryStart, d.Length)
+				if suffix := i == 0 && len(d.Copies) > 1; suffix != d.IsInverted {
+					pc.refStart = d.RefEnd - n
+				} else {
+					pc.refEnd = d.RefStart + n
+				}
+			}
+			placed = append(placed, pc)
+		}
+	}
+	return placed
+}
+
+// project returns the query locus of the reference region [start, end) within the copy.
+// Copies with indels are projected linearly and clamped to the copy.
+func (pc placedCopy) project(start, end int) Locus {
+	qs, qe := start-pc.refStart, end-pc.refStart
+	if pc.inverted {
+		qs, qe = pc.refEnd-end, pc.refEnd-start
+	}
+	clamp := func(x int) int { return min(max(pc.QueryStart+x, pc.QueryStart), pc.QueryEnd) }
+	return Locus{QueryStart: clamp(qs), QueryEnd: clamp(qe), IsInverted: pc.inverted}
+}
+
+// FindInterspersed reports the reference regions of at least minLength bases that two or more
+// copies of the duplicates cover and that are either held at separated query positions or
+// held more often by the query than by the reference. Regions are the maximal reference
+// intervals covered by the same set of copies.
+func FindInterspersed(ref string, dups []Duplicate, minLength int) []Interspersed {
+	ref = strings.ToUpper(ref)
+	copies := slices.DeleteFunc(placeCopies(dups), func(c placedCopy) bool { return c.refStart >= c.refEnd })
+	sort.SliceStable(copies, func(i, j int) bool { return copies[i].refStart < copies[j].refStart })
+	byEnd := make([]int, len(copies))
+	for k := range byEnd {
+		byEnd[k] = k
+	}
+	sort.SliceStable(byEnd, func(i, j int) bool { return copies[byEnd[i]].refEnd < copies[byEnd[j]].refEnd })
+
+	// 沿参考序列扫描拷贝的端点：每个端点都改变覆盖集合，相邻两个端点之间的区间由同一组拷贝覆盖
+	var refSAM *SAM
+	var regions []Interspersed
+	var active []int // Copies covering the current interval, in order of refStart
+	started, ended := 0, 0
+	nextBound := func() int {
+		bound := copies[byEnd[ended]].refEnd
+		if started < len(copies) {
+			bound = min(bound, copies[started].refStart)
+		}
+		return bound
+	}
+	for ended < len(byEnd) {
+		start := nextBound()
+		for ; ended < len(byEnd) && copies[byEnd[ended]].refEnd == start; ended++ {
+			i := slices.Index(active, byEnd[ended])
+			active = slices.Delete(active, i, i+1)
+		}
+		for ; started < len(copies) && copies[started].refStart == start; started++ {
+			active = append(active, started)
+		}
+		if len(active) < 2 {
+			continue
+		}
+		if end := nextBound(); end-start >= minLength {
+			if refSAM == nil {
+				refSAM = BuildSAMByString(ref)
+			}
+			if r, ok := describeRegion(ref, refSAM, copies, active, start, end); ok {
+				regions = append(regions, r)
+			}
+		}
+	}
+	return regions
+}
+
+// describeRegion builds the report of the reference region [start, end) covered by the given
+// copies, and reports whether it is an interspersed duplication. refSAM is the automaton of ref.
+func describeRegion(ref string, refSAM *SAM, copies []placedCopy, covering []int, start, end int) (Interspersed, bool) {
+	r := Interspersed{RefStart: start, RefEnd: end, QueryCopies: len(covering)}
+	for _, k := range covering {
+		r.Loci = append(r.Loci, copies[k].project(start, end))
+	}
+	sort.Slice(r.Loci, func(i, j int) bool { return r.Loci[i].QueryStart < r.Loci[j].QueryStart })
+
+	// 相邻两个拷贝之间的其他序列不短于区域本身时，视为分散重复而非串联重复
+	for i := 1; i < len(r.Loci); i++ {
+		if r.Loci[i].QueryStart-r.Loci[i-1].QueryEnd >= end-start {
+			r.Separated = true
+		}
+	}
+
+	region := ref[start:end]
+	r.RefCopies = len(refSAM.Occurrences(region))
+	if rc := sequence.ReverseComplement(region); rc != region {
+		r.RefCopies += len(refSAM.Occurrences(rc))
+	}
+	return r, r.Separated || r.QueryCopies > r.RefCopies
+}
@@ -0,0 +1,55 @@
+package dups
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func formatInterspersed(regions []Interspersed) string {
+	var sb strings.Builder
+	for _, r := range regions {
+		fmt.Fprintf(&sb, "ref=%d-%d query_copies=%d ref_copies=%d separated=%v loci=%s\n",
+			r.RefStart, r.RefEnd, r.QueryCopies, r.RefCopies, r.Separated, formatLoci(r.Loci))
+	}
+	return sb.String()
+}
+
+func formatLoci(loci []Locus) string {
+	parts := make([]string, len(loci))
+	for i, l := range loci {
+		strand := "+"
+		if l.IsInverted {
+			strand = "-"
+		}
+		parts[i] = fmt.Sprintf("%d-%d%s", l.QueryStart, l.QueryEnd, strand)
+	}
+	return strings.Join(parts, ",")
+}
+
+// TestFindInterspersed inserts copies of a reference segment away from its original position.
+func TestFindInterspersed(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	ref := simulate.RandomSequence(rng, 600, 0.5)
+	segment := ref[100:160]
+	cases := []struct {
+		name  string
+		query string
+	}{
+		{"forward copy", ref[:400] + segment + ref[400:]},
+		{"inverted copy", ref[:400] + sequence.ReverseComplement(segment) + ref[400:]},
+		{"two copies", ref[:250] + segment + ref[250:450] + sequence.ReverseComplement(segment) + ref[450:]},
+		{"tandem copy", ref[:160] + segment + ref[160:]},
+		{"no duplication", ref},
+	}
+	var sb strings.Builder
+	for _, c := range cases {
+		dups := mustAnalyze(t, c.query, ref, Options{})
+		fmt.Fprintf(&sb, "== %s\n%s", c.name, formatInterspersed(FindInterspersed(ref, dups, 20)))
+	}
+	golden.AssertString(t, sb.String())
+}
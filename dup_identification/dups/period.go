@@ -0,0 +1,152 @@
+package dups
+
+// repeatPeriod returns the length of the shortest unit u, at least minLength long, such that s
+// is two or more copies of u followed by a prefix of u. It returns len(s) when s is not such
+// a repeat.
+func repeatPeriod(s string, minLength int) int {
+	// fail[i] is the length of the longest proper border (prefix that is also a suffix) of s[:i].
+	fail := make([]int, len(s)+1)
+	for i, k := 1, 0; i < len(s); i++ {
+		for k > 0 && s[i] != s[k] {
+			k = fail[k]
+		}
+		if s[i] == s[k] {
+			k++
+		}
+		fail[i+1] = k
+	}
+	// Every border b gives a period len(s)-b; the longest border gives the shortest period.
+	for b := fail[len(s)]; b > 0; b = fail[b] {
+		if period := len(s) - b; period >= max(minLength, 1) && 2*period <= len(s) {
+			return period
+		}
+	}
+	return len(s)
+}
+
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// mergeRepeatArrays merges rows that describe one repeat array. Back-to-back rows of exact
+// copies, on the same strand and with units of the same length, whose combined query span
+// still repeats with that period become one row. Then the bases that the row before an array
+// of exact copies took from the array, because its reference match ran into it, are given back
+// to the array as copies and a leading partial copy; the row before is shortened, or dropped
+// when fewer than minUnit bases (and at least one) remain. refSAM, the automaton of ref, lists
+// the occurrences of a shortened row's unit; with a nil refSAM they are left nil.
+func mergeRepeatArrays(query, ref string, refSAM *SAM, dups []Duplicate, minUnit int) []Duplicate {
+	var merged []Duplicate
+	for _, d := range dups {
+		if n := len(merged); n > 0 && sameRepeatArray(query, merged[n-1], d) {
+			a := &merged[n-1]
+			a.Copies, a.Count = periodicCopies(a.QueryStart, firstFullCopy(*a), d.QueryEnd, a.Length)
+			a.QueryEnd = d.QueryEnd
+			continue
+		}
+		merged = append(merged, d)
+	}
+
+	var out []Duplicate
+	for _, d := range merged {
+		n := len(out)
+		if n == 0 || d.Count < 2 || !exactCopies(d) {
+			out = append(out, d)
+			continue
+		}
+		prev := &out[n-1]
+		if prev.QueryEnd != d.QueryStart || prev.Count != 1 || len(prev.Copies) != 1 || !exactCopies(*prev) {
+			out = append(out, d)
+			continue
+		}
+		// Count the bases at the end of the previous row that continue the array backwards.
+		k := 0
+		for k < prev.Length && query[d.QueryStart-k-1] == query[d.QueryStart-k-1+d.Length] {
+			k++
+		}
+		if k == 0 {
+			out = append(out, d)
+			continue
+		}
+		d.Copies, d.Count = periodicCopies(d.QueryStart-k, d.QueryStart-k/d.Length*d.Length, d.QueryEnd, d.Length)
+		d.QueryStart -= k
+		if kept := prev.Length - k; kept == 0 || kept < minUnit {
+			out = out[:n-1]
+		} else {
+			*prev = trimRow(ref, refSAM, *prev, kept)
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func sameRepeatArray(query string, a, b Duplicate) bool {
+	if a.QueryEnd != b.QueryStart || a.IsInverted != b.IsInverted || a.Length != b.Length || !exactCopies(a) || !exactCopies(b) {
+		return false
+	}
+	for i := firstFullCopy(a) + a.Length; i < b.QueryEnd; i++ {
+		if query[i] != query[i-a.Length] {
+			return false
+		}
+	}
+	return true
+}
+
+func exactCopies(d Duplicate) bool {
+	for _, c := range d.Copies {
+		if c.Edits > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// firstFullCopy returns the query start of the first full copy of d.
+func firstFullCopy(d Duplicate) int {
+	if len(d.Copies) > 0 && d.Copies[0].Partial {
+		return d.Copies[0].QueryEnd
+	}
+	return d.QueryStart
+}
+
+// periodicCopies splits the query span [start, end), whose full copies of length period start
+// at firstFull, into a leading partial copy, full copies and a trailing partial copy. It also
+// returns the number of full copies.
+func periodicCopies(start, firstFull, end, period int) ([]Copy, int) {
+	var copies []Copy
+	if firstFull > start {
+		copies = append(copies, Copy{QueryStart: start, QueryEnd: firstFull, Identity: 1, Partial: true})
+	}
+	count := 0
+	for s := firstFull; s < end; s += period {
+		c := Copy{QueryStart: s, QueryEnd: min(s+period, end), Identity: 1}
+		if c.Partial = c.QueryEnd-s < period; !c.Partial {
+			count++
+		}
+		copies = append(copies, c)
+	}
+	return copies, count
+}
+
+// trimRow keeps the first length query bases of a single-copy row. The reference span keeps
+// the bases matched by them: its start for a forward row and its end for an inverted one.
+// RefOccurrences is listed anew when refSAM is not nil.
+func trimRow(ref string, refSAM *SAM, d Duplicate, length int) Duplicate {
+	d.QueryEnd = d.QueryStart + length
+	d.Copies = []Copy{{QueryStart: d.QueryStart, QueryEnd: d.QueryEnd, Identity: 1}}
+	if d.IsInverted {
+		d.RefStart = d.RefEnd - length
+	} else {
+		d.RefEnd = d.RefStart + length
+	}
+	d.Length = length
+	d.RefOccurrences = nil
+	if refSAM != nil {
+		d.RefOccurrences = refSAM.Occurrences(ref[d.RefStart:d.RefEnd])
+	}
+	return d
+}
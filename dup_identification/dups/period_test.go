@@ -0,0 +1,67 @@
+package dups
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/sequence"
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"DNA-Sequence-Alignments/internal/golden"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRepeatPeriod(t *testing.T) {
+	cases := []struct {
+		s         string
+		minLength int
+		want      int
+	}{
+		{"ACGACGACG", 0, 3},
+		{"ACGACGAC", 0, 3},
+		{"ACGACGA", 0, 3},
+		{"ACGAC", 0, 5}, // fewer than two full copies
+		{"AAAAAA", 0, 1},
+		{"AAAAAA", 2, 2},
+		{"ACACACAC", 3, 4},
+		{"ACACACAC", 5, 8},
+		{"ACGTTGCA", 0, 8},
+		{"", 0, 0},
+	}
+	for _, c := range cases {
+		if got := repeatPeriod(c.s, c.minLength); got != c.want {
+			t.Errorf("repeatPeriod(%q, %d) = %d, want %d", c.s, c.minLength, got, c.want)
+		}
+	}
+}
+
+// TestAnalyzePeriodicUnits places short tandem arrays, present once in the reference, in a
+// query whose other bases only produce short chance matches.
+func TestAnalyzePeriodicUnits(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	array := strings.Repeat("ACG", 3)
+	ref := simulate.RandomSequence(rng, 200, 0.5) + array + simulate.RandomSequence(rng, 200, 0.5)
+	query := simulate.RandomSequence(rng, 20, 0.5) + strings.Repeat("ACG", 5) + "AC" + simulate.RandomSequence(rng, 20, 0.5) +
+		sequence.ReverseComplement(array) + simulate.RandomSequence(rng, 20, 0.5)
+	var sb strings.Builder
+	for _, minUnit := range []int{0, 3, 6} {
+		fmt.Fprintf(&sb, "== min unit %d\n%s", minUnit, formatCopies(mustAnalyze(t, query, ref, Options{MinUnitLength: minUnit})))
+	}
+	golden.AssertString(t, sb.String())
+}
+
+func TestMergeRepeatArrays(t *testing.T) {
+	query := "TT" + strings.Repeat("ACGT", 4) + "AC" + "GG"
+	exact := func(start, end int) Copy { return Copy{QueryStart: start, QueryEnd: end, Identity: 1} }
+	rows := []Duplicate{
+		{QueryStart: 0, QueryEnd: 2, Length: 2, Count: 1, Copies: []Copy{exact(0, 2)}},
+		{QueryStart: 2, QueryEnd: 10, RefStart: 5, RefEnd: 9, Length: 4, Count: 2, Copies: []Copy{exact(2, 6), exact(6, 10)}},
+		{QueryStart: 10, QueryEnd: 20, RefStart: 7, RefEnd: 11, Length: 4, Count: 2, Copies: []Copy{exact(10, 14), exact(14, 18), {QueryStart: 18, QueryEnd: 20, Identity: 1, Partial: true}}},
+		{QueryStart: 20, QueryEnd: 22, Length: 4, Count: 1, Copies: []Copy{exact(20, 22)}},
+	}
+	ref := strings.Repeat("A", 5) + "ACGT" + strings.Repeat("A", 5)
+	got := mergeRepeatArrays(query, ref, BuildSAMByString(ref), rows, 0)
+	if len(got) != 3 {
+		t.Fatalf("got %d rows, want 3:\n%s", len(got), formatCopies(got))
+	}
+	golden.AssertString(t, formatCopies(got))
+}
@@ -0,0 +1,250 @@
+package dups
+
+import (
+	"slices"
+	"sort"
+)
+
+// samAlphabet is the number of transitions per state: A, C, G, T and samSeparator.
+const samAlphabet = 5
+
+// samSeparator is the code of N, the other IUPAC ambiguity codes and every other byte that is not
+// a base. Separators break matches: the automaton indexes them like any character, but no match,
+// matching statistic or occurrence ever contains one, so runs of N (gaps in an assembly) and
+// ambiguity codes are neither matched nor merged with each other.
+const samSeparator = 4
+
+// samCode maps a byte to its transition index.
+var samCode [256]uint8
+
+func init() {
+	for i := range samCode {
+		samCode[i] = samSeparator
+	}
+	for i, c := range "ACGT" {
+		samCode[c] = uint8(i)
+		samCode[c-'A'+'a'] = uint8(i)
+	}
+}
+
+// SAM is a suffix automaton over nucleotides. States live in flat slices indexed by state
+// number; next holds samAlphabet transitions per state, 0 meaning none (the initial state 0
+// is never the target of a transition).
+type SAM struct {
+	last     int32
+	len      []int32
+	link     []int32
+	firstPos []int32 // End position of the first occurrence of the state's substrings
+	next     []int32
+
+	// Suffix-link tree: the children of state v are children[childStart[v]:childStart[v+1]].
+	// Built by Occurrences on first use and dropped by Extend.
+	children   []int32
+	childStart []int32
+}
+
+// NewSAM returns the automaton of the empty string, to be grown with Extend.
+func NewSAM() *SAM {
+	s := &SAM{}
+	s.addState(0, -1, 0)
+	return s
+}
+
+// BuildSAMByString builds the automaton of s, reserving room for the at most 2*len(s) states.
+func BuildSAMByString(s string) *SAM {
+	sam := &SAM{
+		len:      make([]int32, 0, 2*len(s)+1),
+		link:     make([]int32, 0, 2*len(s)+1),
+		firstPos: make([]int32, 0, 2*len(s)+1),
+		next:     make([]int32, 0, (2*len(s)+1)*samAlphabet),
+	}
+	sam.addState(0, -1, 0)
+	for i := 0; i < len(s); i++ {
+		sam.Extend(s[i])
+	}
+	return sam
+}
+
+func (s *SAM) addState(length, link, firstPos int32) int32 {
+	s.len = append(s.len, length)
+	s.link = append(s.link, link)
+	s.firstPos = append(s.firstPos, firstPos)
+	s.next = append(s.next, make([]int32, samAlphabet)...)
+	return int32(len(s.len) - 1)
+}
+
+func (s *SAM) transitions(state int32) []int32 {
+	return s.next[int(state)*samAlphabet : int(state+1)*samAlphabet]
+}
+
+// Extend appends c to the indexed string.
+func (s *SAM) Extend(c byte) {
+	code := samCode[c]
+	s.children, s.childStart = nil, nil
+	p := s.last
+	cur := s.addState(s.len[p]+1, -1, s.len[p])
+
+	for ; p != -1 && s.next[int(p)*samAlphabet+int(code)] == 0; p = s.link[p] {
+		s.next[int(p)*samAlphabet+int(code)] = cur
+	}
+
+	if p == -1 {
+		s.link[cur] = 0
+	} else {
+		q := s.next[int(p)*samAlphabet+int(code)]
+		if s.len[p]+1 == s.len[q] {
+			s.link[cur] = q
+		} else {
+			clone := s.addState(s.len[p]+1, s.link[q], s.firstPos[q])
+			copy(s.transitions(clone), s.transitions(q))
+
+			for ; p != -1 && s.next[int(p)*samAlphabet+int(code)] == q; p = s.link[p] {
+				s.next[int(p)*samAlphabet+int(code)] = clone
+			}
+			s.link[q] = clone
+			s.link[cur] = clone
+		}
+	}
+	s.last = cur
+}
+
+// FindMaxMatch returns the length of the longest prefix of query[start:] that occurs in the
+// indexed string and holds no separator.
+func (s *SAM) FindMaxMatch(query string, start int) int {
+	maxLen, _ := s.FindMaxMatchPos(query, start)
+	return maxLen
+}
+
+// FindMaxMatchPos returns the length of the longest prefix of query[start:] that occurs in the
+// indexed string and holds no separator, and the start of its first occurrence there (0 when the
+// length is 0).
+func (s *SAM) FindMaxMatchPos(query string, start int) (int, int) {
+	maxLen := 0
+	cur := int32(0)
+	for i := start; i < len(query); i++ {
+		code := samCode[query[i]]
+		if code == samSeparator {
+			break
+		}
+		next := s.next[int(cur)*samAlphabet+int(code)]
+		if next == 0 {
+			break
+		}
+		cur = next
+		maxLen++
+	}
+	if maxLen == 0 {
+		return 0, 0
+	}
+	return maxLen, int(s.firstPos[cur]) - maxLen + 1
+}
+
+// Match is the longest match of a query position in the indexed string.
+type Match struct {
+	Length int // Length of the match, 0 when the base does not occur
+	RefEnd int // Exclusive end of one occurrence of the match in the indexed string
+}
+
+// MatchingStatistics returns, for every position i of query, the longest prefix of query[i:]
+// that occurs in the indexed string and holds no separator, in O(len(query)) time.
+//
+// A single left-to-right pass follows transitions and falls back along suffix links on a
+// mismatch, which yields for every end position e the longest suffix of query[:e+1] that occurs
+// and the state holding it. The start of that suffix never decreases with e, so the longest match
+// starting at i ends at the last e whose suffix starts at or before i.
+func (s *SAM) MatchingStatistics(query string) []Match {
+	type suffixMatch struct {
+		start int   // Start of the longest occurring suffix ending at e (e+1 when empty)
+		state int32 // State holding it
+	}
+	ends := make([]suffixMatch, len(query))
+	cur, length := int32(0), 0
+	for e := 0; e < len(query); e++ {
+		code := int(samCode[query[e]])
+		if code == samSeparator {
+			cur, length = 0, 0
+			ends[e] = suffixMatch{start: e + 1}
+			continue
+		}
+		for cur != 0 && s.next[int(cur)*samAlphabet+code] == 0 {
+			cur = s.link[cur]
+			length = int(s.len[cur])
+		}
+		if next := s.next[int(cur)*samAlphabet+code]; next != 0 {
+			cur = next
+			length++
+		}
+		ends[e] = suffixMatch{start: e - length + 1, state: cur}
+	}
+
+	stats := make([]Match, len(query))
+	e := -1
+	for i := range query {
+		for e+1 < len(query) && ends[e+1].start <= i {
+			e++
+		}
+		if e >= i {
+			// query[i:e+1] is a suffix of the match ending at e, so it ends where that match does.
+			stats[i] = Match{Length: e - i + 1, RefEnd: int(s.firstPos[ends[e].state]) + 1}
+		}
+	}
+	return stats
+}
+
+// Occurrences returns the start of every (possibly overlapping) occurrence of pattern in the
+// indexed string, in increasing order; a pattern holding a separator has none. It takes O(len(pattern) + k log k) time for k occurrences.
+//
+// The end positions of the state reached by pattern are the first positions of the states in
+// its suffix-link subtree that were not created as clones; a clone is the only kind of state
+// whose first position is not its length minus one. The first call builds the suffix-link tree,
+// so Occurrences must not run concurrently with itself or Extend.
+func (s *SAM) Occurrences(pattern string) []int {
+	if pattern == "" {
+		return nil
+	}
+	cur := int32(0)
+	for i := 0; i < len(pattern); i++ {
+		code := samCode[pattern[i]]
+		if code == samSeparator {
+			return nil
+		}
+		if cur = s.next[int(cur)*samAlphabet+int(code)]; cur == 0 {
+			return nil
+		}
+	}
+	if s.childStart == nil {
+		s.buildLinkTree()
+	}
+	var positions []int
+	stack := []int32{cur}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if s.firstPos[v] == s.len[v]-1 {
+			positions = append(positions, int(s.firstPos[v])-len(pattern)+1)
+		}
+		stack = append(stack, s.children[s.childStart[v]:s.childStart[v+1]]...)
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+// buildLinkTree lays out the children of every state in the suffix-link tree, grouped by parent.
+func (s *SAM) buildLinkTree() {
+	n := len(s.len)
+	start := make([]int32, n+1)
+	for v := 1; v < n; v++ {
+		start[s.link[v]+1]++
+	}
+	for v := 0; v < n; v++ {
+		start[v+1] += start[v]
+	}
+	children := make([]int32, n-1)
+	fill := slices.Clone(start[:n])
+	for v := 1; v < n; v++ {
+		p := s.link[v]
+		children[fill[p]] = int32(v)
+		fill[p]++
+	}
+	s.children, s.childStart = children, start
+}
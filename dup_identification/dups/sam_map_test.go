@@ -0,0 +1,254 @@
+package dups
+
+import (
+	"DNA-Sequence-Alignments/dna_aligner/simulate"
+	"fmt"
+	"maps"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mapSAM is the previous suffix automaton, with one map of transitions per state. It is kept
+// to check the flat-array SAM against and to benchmark it.
+
+type mapState struct {
+	len      int
+	link     int
+	firstPos int // End position of the first occurrence of the state's substrings
+	next     map[byte]int
+}
+
+func newMapState(len, link int) *mapState {
+	return &mapState{len: len, link: link, next: make(map[byte]int)}
+}
+
+type mapSAM struct {
+	last   int
+	size   int
+	states []*mapState
+}
+
+func newMapSAM() *mapSAM {
+	return &mapSAM{
+		last:   0,
+		size:   1,
+		states: []*mapState{newMapState(0, -1)},
+	}
+}
+
+func buildMapSAM(s string) *mapSAM {
+	sam := newMapSAM()
+	for _, c := range s {
+		sam.Extend(byte(c))
+	}
+	return sam
+}
+
+func (s *mapSAM) Extend(c byte) {
+	p, cur := s.last, s.size
+	s.size++
+	s.states = append(s.states, newMapState(s.states[p].len+1, -1))
+	s.states[cur].len = s.states[p].len + 1
+	s.states[cur].firstPos = s.states[cur].len - 1
+
+	for ; p != -1 && s.states[p].next[c] == 0; p = s.states[p].link {
+		s.states[p].next[c] = cur
+	}
+
+	if p == -1 {
+		s.states[cur].link = 0
+	} else {
+		q := s.states[p].next[c]
+		if s.states[p].len+1 == s.states[q].len {
+			s.states[cur].link = q
+		} else {
+			clone := s.size
+			s.size++
+			s.states = append(s.states, newMapState(s.states[p].len+1, s.states[q].link))
+			s.states[clone].firstPos = s.states[q].firstPos
+
+			maps.Copy(s.states[clone].next, s.states[q].next)
+
+			for ; p != -1 && s.states[p].next[c] == q; p = s.states[p].link {
+				s.states[p].next[c] = clone
+			}
+			s.states[q].link = clone
+			s.states[cur].link = clone
+		}
+	}
+	s.last = cur
+}
+
+func (s *mapSAM) FindMaxMatch(query string, start int) int {
+	maxLen, _ := s.FindMaxMatchPos(query, start)
+	return maxLen
+}
+
+// FindMaxMatchPos returns the length of the longest prefix of query[start:] that occurs in the
+// indexed string and holds only A, C, G and T, and the start of its first occurrence there
+// (0 when the length is 0).
+func (s *mapSAM) FindMaxMatchPos(query string, start int) (int, int) {
+	maxLen := 0
+	cur := 0
+	for i := start; i < len(query); i++ {
+		c := query[i]
+		if _, ok := s.states[cur].next[c]; !ok || !strings.ContainsRune("ACGTacgt", rune(c)) {
+			break
+		}
+		cur = s.states[cur].next[c]
+		maxLen++
+	}
+	if maxLen == 0 {
+		return 0, 0
+	}
+	return maxLen, s.states[cur].firstPos - maxLen + 1
+}
+
+// TestSAMMatchesMapSAM compares the longest matches of both automata on random sequences
+// with N and lower-case bases.
+func TestSAMMatchesMapSAM(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for trial := 0; trial < 20; trial++ {
+		ref := []byte(simulate.RandomSequence(rng, 300, 0.5))
+		ref[rng.Intn(len(ref))] = 'N'
+		query := ref[100:200:200]
+		query = append(query, simulate.RandomSequence(rng, 200, 0.4)...)
+		query[rng.Intn(len(query))] = 'N'
+
+		array, mapped := BuildSAMByString(string(ref)), buildMapSAM(string(ref))
+		for start := range query {
+			gotLen, gotPos := array.FindMaxMatchPos(string(query), start)
+			wantLen, wantPos := mapped.FindMaxMatchPos(string(query), start)
+			if gotLen != wantLen || gotPos != wantPos {
+				t.Fatalf("trial %d, start %d: got (%d, %d), want (%d, %d)", trial, start, gotLen, gotPos, wantLen, wantPos)
+			}
+		}
+	}
+	if n := BuildSAMByString("acgt").FindMaxMatch("ACGT", 0); n != 4 {
+		t.Errorf("lower-case reference matched %d bases of upper-case query, want 4", n)
+	}
+}
+
+// TestSAMOccurrences compares the occurrences listed by the automaton with a string search,
+// on random sequences and on tandem repeats with overlapping occurrences, before and after the
+// automaton is extended.
+func TestSAMOccurrences(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	refs := []string{"ACACACACAGTACACAC", "AAAAAAAAAA"}
+	for trial := 0; trial < 10; trial++ {
+		refs = append(refs, simulate.RandomSequence(rng, 200, 0.5))
+	}
+	for _, ref := range refs {
+		sam := BuildSAMByString(ref[:len(ref)-3])
+		for _, n := range []int{len(ref) - 3, len(ref)} {
+			if n == len(ref) {
+				for i := len(ref) - 3; i < len(ref); i++ {
+					sam.Extend(ref[i])
+				}
+			}
+			for start := 0; start < n; start++ {
+				for _, length := range []int{1, 2, 5, 12} {
+					pattern := ref[start:min(start+length, n)]
+					if got, want := sam.Occurrences(pattern), occurrences(ref[:n], pattern); !slices.Equal(got, want) {
+						t.Fatalf("ref %q[:%d], pattern %q: got %v, want %v", ref, n, pattern, got, want)
+					}
+				}
+			}
+		}
+		if got := sam.Occurrences("GGGGGGGGGGGGGGGGGGGG"); got != nil {
+			t.Errorf("ref %q: absent pattern occurs at %v", ref, got)
+		}
+	}
+}
+
+// occurrences returns the start of every (possibly overlapping) occurrence of unit in ref.
+func occurrences(ref, unit string) []int {
+	var positions []int
+	for from := 0; ; {
+		i := strings.Index(ref[from:], unit)
+		if i < 0 {
+			return positions
+		}
+		positions = append(positions, from+i)
+		from += i + 1
+	}
+}
+
+// TestSAMSeparators checks that N and the other ambiguity codes break matches: they match
+// neither themselves nor each other, and patterns holding them have no occurrences.
+func TestSAMSeparators(t *testing.T) {
+	sam := BuildSAMByString("ACGTNNNNNNGGCARYACGTRR")
+	for _, q := range []string{"N", "NNNN", "R", "RY", "Y", "X"} {
+		if n := sam.FindMaxMatch(q, 0); n != 0 {
+			t.Errorf("FindMaxMatch(%q) = %d, want 0", q, n)
+		}
+		if got := sam.Occurrences(q); got != nil {
+			t.Errorf("Occurrences(%q) = %v, want none", q, got)
+		}
+	}
+	if n := sam.FindMaxMatch("ACGTNNNN", 0); n != 4 {
+		t.Errorf("FindMaxMatch stops at %d, want 4 before the Ns", n)
+	}
+	var lengths []int
+	for _, m := range sam.MatchingStatistics("GGCANNACGTR") {
+		lengths = append(lengths, m.Length)
+	}
+	if fmt.Sprint(lengths) != "[4 3 2 1 0 0 4 3 2 1 0]" {
+		t.Errorf("matching statistics %v, want [4 3 2 1 0 0 4 3 2 1 0]", lengths)
+	}
+}
+
+var benchmarkRef = simulate.RandomSequence(rand.New(rand.NewSource(1)), 200_000, 0.5)
+
+func BenchmarkBuildSAM(b *testing.B) {
+	b.Run("array", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			BuildSAMByString(benchmarkRef)
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			buildMapSAM(benchmarkRef)
+		}
+	})
+}
+
+func BenchmarkFindMaxMatch(b *testing.B) {
+	query := benchmarkRef[1000:51000] + simulate.RandomSequence(rand.New(rand.NewSource(2)), 50_000, 0.5)
+	array, mapped := BuildSAMByString(benchmarkRef), buildMapSAM(benchmarkRef)
+	b.Run("array", func(b *testing.B) {
+		for b.Loop() {
+			for start := 0; start < len(query); start += 97 {
+				array.FindMaxMatch(query, start)
+			}
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		for b.Loop() {
+			for start := 0; start < len(query); start += 97 {
+				mapped.FindMaxMatch(query, start)
+			}
+		}
+	})
+}
+
+func BenchmarkMatchingStatistics(b *testing.B) {
+	query := benchmarkRef[1000:51000] + simulate.RandomSequence(rand.New(rand.NewSource(2)), 50_000, 0.5)
+	sam := BuildSAMByString(benchmarkRef)
+	b.Run("suffix-links", func(b *testing.B) {
+		for b.Loop() {
+			sam.MatchingStatistics(query)
+		}
+	})
+	b.Run("root-walks", func(b *testing.B) {
+		for b.Loop() {
+			for start := range query {
+				sam.FindMaxMatch(query, start)
+			}
+		}
+	})
+}
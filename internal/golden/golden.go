@@ -0,0 +1,72 @@
+// Package golden compares test output with expected output checked in under testdata/.
+//
+// Run `go test ./... -update` to rewrite the golden files from the current output.
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// Path returns the golden file of the running test: testdata/<test name>.golden,
+// with subtest separators turned into underscores.
+func Path(t testing.TB) string {
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	return filepath.Join("testdata", name+".golden")
+}
+
+// Assert fails the test when got differs from the golden file of the test.
+// With -update the golden file is written instead.
+func Assert(t testing.TB, got []byte) {
+	t.Helper()
+	path := Path(t)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run `go test -update` to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run `go test -update` to accept it)\n%s", path, firstDifference(string(got), string(want)))
+	}
+}
+
+// AssertString is Assert for string output.
+func AssertString(t testing.TB, got string) {
+	t.Helper()
+	Assert(t, []byte(got))
+}
+
+// firstDifference describes the first line where got and want differ.
+func firstDifference(got, want string) string {
+	gotLines := strings.Split(got, "\n")
+	wantLines := strings.Split(want, "\n")
+	for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w || i >= len(gotLines) || i >= len(wantLines) {
+			return fmt.Sprintf("line %d:\n got: %s\nwant: %s", i+1, g, w)
+		}
+	}
+	return ""
+}
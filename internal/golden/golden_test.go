@@ -0,0 +1,25 @@
+package golden
+
+import "testing"
+
+func TestPath(t *testing.T) {
+	t.Run("sub test", func(t *testing.T) {
+		if got, want := Path(t), "testdata/TestPath_sub_test.golden"; got != want {
+			t.Errorf("Path = %q, want %q", got, want)
+		}
+		AssertString(t, "golden files are compared byte for byte\n")
+	})
+}
+
+func TestFirstDifference(t *testing.T) {
+	cases := []struct{ got, want, diff string }{
+		{"a\nb\n", "a\nb\n", ""},
+		{"a\nb\n", "a\nc\n", "line 2:\n got: b\nwant: c"},
+		{"a\n", "a\nb\n", "line 2:\n got: \nwant: b"},
+	}
+	for _, c := range cases {
+		if diff := firstDifference(c.got, c.want); diff != c.diff {
+			t.Errorf("firstDifference(%q, %q) = %q, want %q", c.got, c.want, diff, c.diff)
+		}
+	}
+}